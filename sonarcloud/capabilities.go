@@ -0,0 +1,46 @@
+package sonarcloud
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-version"
+)
+
+// capability is a feature of the web API that only exists from a certain
+// server version on.
+type capability struct {
+	description string
+	minVersion  *version.Version
+}
+
+var (
+	// Quality gates can be selected by name instead of their numeric id
+	capabilityQualityGateByName = capability{
+		description: "Identifying quality gates by name",
+		minVersion:  version.Must(version.NewVersion("8.4")),
+	}
+	// The api/new_code_periods web service replaces the sonar.leak.period setting
+	capabilityNewCodePeriods = capability{
+		description: "The api/new_code_periods web service",
+		minVersion:  version.Must(version.NewVersion("8.0")),
+	}
+	// User tokens can be generated with an expiration date
+	capabilityTokenExpiration = capability{
+		description: "User token expiration",
+		minVersion:  version.Must(version.NewVersion("9.6")),
+	}
+)
+
+// supports reports whether the server supports the capability.
+func (p *ProviderConfiguration) supports(c capability) bool {
+	return p.serverVersion.GreaterThanOrEqual(c.minVersion)
+}
+
+// requireCapability returns an error naming the required server version when
+// the server does not support the capability.
+func (p *ProviderConfiguration) requireCapability(c capability) error {
+	if p.supports(c) {
+		return nil
+	}
+	return fmt.Errorf("%s requires server version >= %s, the server runs %s", c.description, c.minVersion, p.serverVersion)
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// readCache caches the response bodies of GET requests for a short time, so
+// many resources reading the same search results during a refresh cost one
+// request. Concurrent identical requests wait for the first one instead of
+// sending their own. Every mutating request invalidates the cached responses
+// of its domain, e.g. api/permissions/add_group those of api/permissions/*.
+type readCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	// generations counts the invalidations per domain, so a response that
+	// was requested before an invalidation is not cached after it
+	generations map[string]int
+}
+
+type cacheEntry struct {
+	domain     string
+	generation int
+	expires    time.Time
+	// ready is closed once body, err and cancelled are set
+	ready chan struct{}
+	body  []byte
+	err   error
+	// cancelled is set when the fetch failed because the context of the
+	// request that sent it ended
+	cancelled bool
+}
+
+func newReadCache(ttl time.Duration) *readCache {
+	return &readCache{
+		ttl:         ttl,
+		entries:     map[string]*cacheEntry{},
+		generations: map[string]int{},
+	}
+}
+
+// get returns the cached body for key, or calls fetch to get it. Failed
+// fetches are not cached, but are returned to requests that waited for them.
+// When a fetch failed only because the context of its request ended, a
+// waiting request fetches again with its own context instead. A request
+// whose own context ends while waiting returns the context's error.
+func (c *readCache) get(ctx context.Context, key string, domain string, fetch func() ([]byte, error)) ([]byte, bool, error) {
+	for {
+		c.mu.Lock()
+		e, ok := c.entries[key]
+		if ok && (e.expires.IsZero() || time.Now().Before(e.expires)) {
+			c.mu.Unlock()
+			select {
+			case <-e.ready:
+			case <-ctx.Done():
+				return nil, false, ctx.Err()
+			}
+			if e.cancelled {
+				continue
+			}
+			return e.body, true, e.err
+		}
+
+		e = &cacheEntry{
+			domain:     domain,
+			generation: c.generations[domain],
+			ready:      make(chan struct{}),
+		}
+		c.entries[key] = e
+		c.mu.Unlock()
+
+		e.body, e.err = fetch()
+		e.cancelled = e.err != nil && ctx.Err() != nil
+
+		c.mu.Lock()
+		if e.err != nil || e.generation != c.generations[domain] {
+			if c.entries[key] == e {
+				delete(c.entries, key)
+			}
+		} else {
+			e.expires = time.Now().Add(c.ttl)
+		}
+		c.mu.Unlock()
+		close(e.ready)
+
+		return e.body, false, e.err
+	}
+}
+
+// relatedDomains are domains whose responses change with those of another
+// domain, e.g. the tags set through api/project_tags are read through
+// api/components/show.
+var relatedDomains = map[string][]string{
+	"project_tags": {"components"},
+	"projects":     {"components"},
+}
+
+// invalidate drops the cached responses of domain and its related domains.
+func (c *readCache) invalidate(domain string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, d := range append([]string{domain}, relatedDomains[domain]...) {
+		c.generations[d]++
+		for key, e := range c.entries {
+			if e.domain == d {
+				delete(c.entries, key)
+			}
+		}
+	}
+}
+
+// domain returns the web service domain of the endpoint at path, e.g.
+// "permissions" for api/permissions/groups.
+func domain(path string) string {
+	return strings.SplitN(strings.TrimPrefix(strings.TrimPrefix(path, "/"), "api/"), "/", 2)[0]
+}
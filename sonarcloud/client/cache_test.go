@@ -0,0 +1,250 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingFetch returns a fetch answering with the number of its calls.
+func countingFetch(calls *int32) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		return []byte(fmt.Sprint(atomic.AddInt32(calls, 1))), nil
+	}
+}
+
+// errFetch is the error of failing fetches.
+var errFetch = errors.New("fetch failed")
+
+// cancelledContext returns a context that has ended.
+func cancelledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+func TestReadCacheInvalidate(t *testing.T) {
+	tests := []struct {
+		name string
+		// domain is the domain of the cached read, write the domain of
+		// the mutating request
+		domain string
+		write  string
+		// fetched is true when the read is sent again after the write
+		fetched bool
+	}{
+		{name: "same domain", domain: "permissions", write: "permissions", fetched: true},
+		{name: "related domain", domain: "components", write: "project_tags", fetched: true},
+		{name: "other domain", domain: "user_groups", write: "permissions", fetched: false},
+		{name: "unrelated domain", domain: "project_tags", write: "components", fetched: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cache := newReadCache(time.Minute)
+			ctx := context.Background()
+			var calls int32
+
+			if _, cached, err := cache.get(ctx, "key", test.domain, countingFetch(&calls)); err != nil || cached {
+				t.Fatalf("expected a fetch, got cached %v and %v", cached, err)
+			}
+			if _, cached, err := cache.get(ctx, "key", test.domain, countingFetch(&calls)); err != nil || !cached {
+				t.Fatalf("expected a cached response, got cached %v and %v", cached, err)
+			}
+
+			cache.invalidate(test.write)
+			body, cached, err := cache.get(ctx, "key", test.domain, countingFetch(&calls))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cached == test.fetched {
+				t.Errorf("expected the read to be fetched again to be %v, got cached %v", test.fetched, cached)
+			}
+			if want := map[bool]string{false: "1", true: "2"}[test.fetched]; string(body) != want {
+				t.Errorf("expected the body %q, got %q", want, body)
+			}
+		})
+	}
+}
+
+func TestReadCacheWriteDuringFetch(t *testing.T) {
+	cache := newReadCache(time.Minute)
+	ctx := context.Background()
+	var calls int32
+
+	// The response was requested before the write, it is returned but
+	// dropped from the cache
+	body, _, err := cache.get(ctx, "key", "permissions", func() ([]byte, error) {
+		cache.invalidate("permissions")
+		return countingFetch(&calls)()
+	})
+	if err != nil || string(body) != "1" {
+		t.Fatalf("expected the body 1, got %q and %v", body, err)
+	}
+
+	body, cached, err := cache.get(ctx, "key", "permissions", countingFetch(&calls))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached || string(body) != "2" {
+		t.Errorf("expected the read to be fetched again, got %q cached %v", body, cached)
+	}
+}
+
+func TestReadCacheSharedFetch(t *testing.T) {
+	const waiters = 5
+	cache := newReadCache(time.Minute)
+	ctx := context.Background()
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	bodies := make([]string, waiters+1)
+	errs := make([]error, waiters+1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var body []byte
+		body, _, errs[0] = cache.get(ctx, "key", "projects", func() ([]byte, error) {
+			close(started)
+			<-release
+			return countingFetch(&calls)()
+		})
+		bodies[0] = string(body)
+	}()
+
+	<-started
+	for i := 1; i <= waiters; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var body []byte
+			body, _, errs[i] = cache.get(ctx, "key", "projects", countingFetch(&calls))
+			bodies[i] = string(body)
+		}(i)
+	}
+	// Give the waiters time to find the pending fetch
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("expected one fetch, got %d", calls)
+	}
+	for i := range bodies {
+		if errs[i] != nil || bodies[i] != "1" {
+			t.Errorf("expected request %d to get the body 1, got %q and %v", i, bodies[i], errs[i])
+		}
+	}
+}
+
+func TestReadCacheFailedFetch(t *testing.T) {
+	tests := []struct {
+		name string
+		// fetchCtx is the context of the request sending the failing
+		// fetch, waitCtx that of the request waiting for it
+		fetchCtx func() context.Context
+		waitCtx  func() context.Context
+		// want is what the waiting request gets
+		want string
+		err  error
+	}{
+		{
+			name:     "error is shared",
+			fetchCtx: context.Background,
+			waitCtx:  context.Background,
+			err:      errFetch,
+		},
+		{
+			name:     "fetching request cancelled",
+			fetchCtx: cancelledContext,
+			waitCtx:  context.Background,
+			want:     "1",
+		},
+		{
+			name:     "waiting request cancelled",
+			fetchCtx: context.Background,
+			waitCtx:  cancelledContext,
+			err:      context.Canceled,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cache := newReadCache(time.Minute)
+			var calls int32
+			started := make(chan struct{})
+			release := make(chan struct{})
+
+			fetchErr := make(chan error)
+			go func() {
+				_, _, err := cache.get(test.fetchCtx(), "key", "projects", func() ([]byte, error) {
+					close(started)
+					<-release
+					return nil, errFetch
+				})
+				fetchErr <- err
+			}()
+			<-started
+
+			waited := make(chan struct{})
+			var body []byte
+			var err error
+			go func() {
+				body, _, err = cache.get(test.waitCtx(), "key", "projects", countingFetch(&calls))
+				close(waited)
+			}()
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+			<-waited
+
+			if e := <-fetchErr; !errors.Is(e, errFetch) {
+				t.Errorf("expected the error of the fetch, got %v", e)
+			}
+			if !errors.Is(err, test.err) || string(body) != test.want {
+				t.Errorf("expected %q and %v, got %q and %v", test.want, test.err, body, err)
+			}
+
+			// Failures are not cached
+			body, cached, err := cache.get(context.Background(), "key", "projects", countingFetch(&calls))
+			if err != nil || cached != (test.want != "") {
+				t.Errorf("expected cached %v, got %v and %v", test.want != "", cached, err)
+			}
+		})
+	}
+}
+
+func TestReadCacheExpiry(t *testing.T) {
+	cache := newReadCache(10 * time.Millisecond)
+	ctx := context.Background()
+	var calls int32
+
+	if _, _, err := cache.get(ctx, "key", "projects", countingFetch(&calls)); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	body, cached, err := cache.get(ctx, "key", "projects", countingFetch(&calls))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached || string(body) != "2" {
+		t.Errorf("expected the expired read to be fetched again, got %q cached %v", body, cached)
+	}
+}
+
+func TestDomain(t *testing.T) {
+	tests := map[string]string{
+		"api/permissions/add_group": "permissions",
+		"/api/permissions/groups":   "permissions",
+		"api/projects/search":       "projects",
+		"api/server/version":        "server",
+	}
+	for path, want := range tests {
+		if got := domain(path); got != want {
+			t.Errorf("expected the domain of %s to be %s, got %s", path, want, got)
+		}
+	}
+}
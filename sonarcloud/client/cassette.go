@@ -0,0 +1,252 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Cassette modes
+const (
+	// CassetteRecord sends requests to the API and writes every request
+	// and response pair to the cassette.
+	CassetteRecord = "record"
+	// CassetteReplay answers requests from the cassette without any
+	// network access.
+	CassetteReplay = "replay"
+)
+
+// unrecordedHeaders are response headers that are never recorded, because
+// they are sensitive or change on every request
+var unrecordedHeaders = map[string]bool{
+	"Authorization":  true,
+	"Content-Length": true,
+	"Cookie":         true,
+	"Date":           true,
+	"Set-Cookie":     true,
+}
+
+// Interaction is a request and the response to it as stored in a cassette.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request stored in a cassette.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	// URL is the request URL without userinfo
+	URL  string `json:"url"`
+	Body string `json:"body,omitempty"`
+}
+
+// RecordedResponse is a response stored in a cassette.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+type cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// CassetteOptions configure a CassetteTransport.
+type CassetteOptions struct {
+	// Path of the cassette file
+	Path string
+	// Mode is CassetteRecord or CassetteReplay
+	Mode string
+	// Secrets are replaced wherever they appear in a recorded request or
+	// response, e.g. the configured token or password.
+	Secrets []string
+}
+
+// CassetteTransport records API traffic to a cassette file, or replays it from
+// one, so tests can run against real API responses without network access.
+// Credentials are scrubbed before anything is written: the URL userinfo,
+// authentication headers, password and token fields and all configured
+// secrets.
+//
+// Replayed requests are matched by method, path, query and body. Every
+// recorded interaction is replayed once, in recorded order, so repeated
+// requests like retries or reads after an update get the response they got
+// when recording.
+type CassetteTransport struct {
+	options  CassetteOptions
+	base     http.RoundTripper
+	scrubber scrubber
+
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     []bool
+}
+
+// openCassettes are the cassettes in use by this process, by path. Terraform
+// configures the provider again for every command, e.g. for the plan and the
+// apply of an acceptance test step, and all of them have to share the
+// cassette.
+var (
+	openCassettesMu sync.Mutex
+	openCassettes   = map[string]*CassetteTransport{}
+)
+
+// NewCassetteTransport returns a transport that records the requests sent
+// through base, or replays them from the cassette at options.Path. A cassette
+// that is already in use by this process in the same mode is shared, it keeps
+// the base transport it was created with.
+func NewCassetteTransport(options CassetteOptions, base http.RoundTripper) (*CassetteTransport, error) {
+	openCassettesMu.Lock()
+	defer openCassettesMu.Unlock()
+
+	if t, ok := openCassettes[options.Path]; ok && t.options.Mode == options.Mode {
+		return t, nil
+	}
+
+	t := &CassetteTransport{
+		options:  options,
+		base:     base,
+		scrubber: scrubber{secrets: options.Secrets},
+	}
+
+	switch options.Mode {
+	case CassetteRecord:
+		// Start with an empty cassette, it is written after every
+		// request
+	case CassetteReplay:
+		data, err := ioutil.ReadFile(options.Path)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read cassette: %w", err)
+		}
+		c := cassette{}
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("Unable to decode cassette %s: %w", options.Path, err)
+		}
+		t.interactions = c.Interactions
+		t.replayed = make([]bool, len(c.Interactions))
+	default:
+		return nil, fmt.Errorf("Unknown cassette mode %q, expected %q or %q", options.Mode, CassetteRecord, CassetteReplay)
+	}
+
+	openCassettes[options.Path] = t
+	return t, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *CassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	recordedRequest := RecordedRequest{
+		Method: req.Method,
+		URL:    t.scrubber.url(req.URL),
+		Body:   t.scrubber.form(body),
+	}
+
+	if t.options.Mode == CassetteReplay {
+		return t.replay(req, recordedRequest)
+	}
+	return t.record(req, recordedRequest)
+}
+
+func (t *CassetteTransport) record(req *http.Request, recordedRequest RecordedRequest) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	header := http.Header{}
+	for key, values := range resp.Header {
+		if !unrecordedHeaders[http.CanonicalHeaderKey(key)] {
+			header[key] = values
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.interactions = append(t.interactions, Interaction{
+		Request: recordedRequest,
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     header,
+			Body:       t.scrubber.json(string(respBody)),
+		},
+	})
+
+	// Write the whole cassette every time, the provider process can end
+	// at any point
+	data := bytes.Buffer{}
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(cassette{Interactions: t.interactions}); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(t.options.Path, data.Bytes(), 0600); err != nil {
+		return nil, fmt.Errorf("Unable to write cassette: %w", err)
+	}
+
+	return resp, nil
+}
+
+func (t *CassetteTransport) replay(req *http.Request, recordedRequest RecordedRequest) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, interaction := range t.interactions {
+		if t.replayed[i] || !sameRequest(interaction.Request, recordedRequest) {
+			continue
+		}
+		t.replayed[i] = true
+
+		header := interaction.Response.Header
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header.Clone(),
+			Body:          ioutil.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("No recorded interaction left in cassette %s for %s %s", t.options.Path, recordedRequest.Method, recordedRequest.URL)
+}
+
+// sameRequest reports whether two recorded requests match. The scheme and
+// host are ignored, so a cassette can be replayed against any host.
+func sameRequest(a RecordedRequest, b RecordedRequest) bool {
+	if a.Method != b.Method || a.Body != b.Body {
+		return false
+	}
+
+	aURL, err := url.Parse(a.URL)
+	if err != nil {
+		return false
+	}
+	bURL, err := url.Parse(b.URL)
+	if err != nil {
+		return false
+	}
+	return aURL.Path == bURL.Path && aURL.Query().Encode() == bURL.Query().Encode()
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/meetdpv/SonarCloud/tests/sonarcloudtest"
+)
+
+// newCassetteClient returns a client that logs in to server with a password
+// and records to, or replays from, the cassette at path.
+func newCassetteClient(t *testing.T, server *sonarcloudtest.Server, path string, mode string) *Client {
+	t.Helper()
+	transport, err := NewCassetteTransport(CassetteOptions{
+		Path:    path,
+		Mode:    mode,
+		Secrets: []string{"admin-password"},
+	}, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := retryablehttp.NewClient()
+	httpClient.Logger = nil
+	httpClient.RetryMax = 0
+	httpClient.HTTPClient.Transport = transport
+	return NewClient(httpClient, url.URL{
+		Scheme: server.Scheme(),
+		Host:   server.Host(),
+		User:   url.UserPassword("admin", "admin-password"),
+	})
+}
+
+// useCassette creates a user and a token, with a new client for each like
+// Terraform configures the provider again for every command.
+func useCassette(t *testing.T, server *sonarcloudtest.Server, path string, mode string) *Token {
+	t.Helper()
+	ctx := context.Background()
+
+	_, err := newCassetteClient(t, server, path, mode).Users.Create(ctx, CreateUserOptions{
+		Login:    "jdoe",
+		Name:     "John Doe",
+		Password: "user-password",
+		Local:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := newCassetteClient(t, server, path, mode).UserTokens.Generate(ctx, "jdoe", "ci", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestCassetteTransport(t *testing.T) {
+	server := sonarcloudtest.NewServer(sonarcloudtest.Options{})
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	token := useCassette(t, server, path, CassetteRecord)
+	if !strings.HasPrefix(token.Token, "squ_") {
+		t.Fatalf("got token %q from the server", token.Token)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cassette := string(data)
+	for _, secret := range []string{"admin-password", "user-password", token.Token, "admin@", "Authorization", "Basic "} {
+		if strings.Contains(cassette, secret) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+	for _, request := range []string{"api/users/create", "api/user_tokens/generate"} {
+		if !strings.Contains(cassette, request) {
+			t.Errorf("cassette does not contain %s", request)
+		}
+	}
+
+	// Nothing may reach the server when replaying
+	server.Close()
+	token = useCassette(t, server, path, CassetteReplay)
+	if token.Login != "jdoe" || token.Name != "ci" || token.Token != redacted {
+		t.Errorf("got token %+v from the cassette", token)
+	}
+
+	// Every interaction is replayed once
+	_, err = newCassetteClient(t, server, path, CassetteReplay).UserTokens.Generate(context.Background(), "jdoe", "ci", "")
+	if err == nil || !strings.Contains(err.Error(), "No recorded interaction left") {
+		t.Errorf("got %v replaying a request twice", err)
+	}
+}
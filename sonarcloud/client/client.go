@@ -0,0 +1,240 @@
+// Package client implements a typed client for the SonarCloud web API. The
+// same API is served by SonarQube, so the client can be used with both.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// Client talks to the SonarCloud web API. Every web service domain is exposed
+// as its own service, e.g. client.Projects.Create(...).
+type Client struct {
+	httpClient *retryablehttp.Client
+	baseURL    url.URL
+	cache      *readCache
+
+	Components      *ComponentsService
+	Navigation      *NavigationService
+	NewCodePeriods  *NewCodePeriodsService
+	Permissions     *PermissionsService
+	Plugins         *PluginsService
+	Projects        *ProjectsService
+	ProjectTags     *ProjectTagsService
+	QualityGates    *QualityGatesService
+	QualityProfiles *QualityProfilesService
+	Server          *ServerService
+	Settings        *SettingsService
+	UserGroups      *UserGroupsService
+	Users           *UsersService
+	UserTokens      *UserTokensService
+}
+
+// NewClient returns a client that sends its requests to baseURL. Endpoint
+// paths are joined onto the path of baseURL, so servers under a context path
+// are supported. Credentials
+// set as userinfo on baseURL are sent as basic auth, use TokenAuthTransport on
+// the http client to authenticate with a user token instead. Set CheckRetry
+// and Backoff of this package on the http client to retry rate limited
+// requests safely.
+func NewClient(httpClient *retryablehttp.Client, baseURL url.URL) *Client {
+	c := &Client{
+		httpClient: httpClient,
+		baseURL:    baseURL,
+	}
+
+	c.Components = &ComponentsService{client: c}
+	c.Navigation = &NavigationService{client: c}
+	c.NewCodePeriods = &NewCodePeriodsService{client: c}
+	c.Permissions = &PermissionsService{client: c}
+	c.Plugins = &PluginsService{client: c}
+	c.Projects = &ProjectsService{client: c}
+	c.ProjectTags = &ProjectTagsService{client: c}
+	c.QualityGates = &QualityGatesService{client: c}
+	c.QualityProfiles = &QualityProfilesService{client: c}
+	c.Server = &ServerService{client: c}
+	c.Settings = &SettingsService{client: c}
+	c.UserGroups = &UserGroupsService{client: c}
+	c.Users = &UsersService{client: c}
+	c.UserTokens = &UserTokensService{client: c}
+
+	return c
+}
+
+// EnableReadCache caches the responses of GET requests for ttl, so identical
+// reads of many resources cost a single request. Any POST request drops the
+// cached responses of its domain, e.g. api/permissions/add_group those of
+// api/permissions/groups. Enable it only for the lifetime of a single
+// Terraform run, changes made by others are not seen until ttl has passed.
+func (c *Client) EnableReadCache(ttl time.Duration) {
+	c.cache = newReadCache(ttl)
+}
+
+// call sends a request to the endpoint at path with params as query string,
+// or as form body for POST requests. If v is not nil the JSON response body is
+// decoded into it.
+func (c *Client) call(ctx context.Context, method string, path string, params url.Values, expectedResponseCode int, v interface{}) error {
+	resp, err := c.do(ctx, method, path, params, expectedResponseCode)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if v == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("Failed to decode %s response json into struct: %+v", path, err)
+	}
+
+	return nil
+}
+
+// do sends a request to the endpoint at path and checks the response code. The
+// caller has to close the body of the returned response. When no response was
+// received a *TransportError is returned, on an unexpected response code an
+// *APIError. GET requests are answered from the read cache when it is enabled.
+func (c *Client) do(ctx context.Context, method string, path string, params url.Values, expectedResponseCode int) (*http.Response, error) {
+	if c.cache == nil {
+		return c.send(ctx, method, path, params, expectedResponseCode)
+	}
+
+	if method != "GET" {
+		// Drop the cached reads of the domain even if the request
+		// failed, it may have changed something anyway
+		defer c.cache.invalidate(domain(path))
+		return c.send(ctx, method, path, params, expectedResponseCode)
+	}
+
+	key := path + "?" + params.Encode()
+	body, cached, err := c.cache.get(ctx, key, domain(path), func() ([]byte, error) {
+		resp, err := c.send(ctx, method, path, params, expectedResponseCode)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, newTransportError(method, path, err)
+		}
+		return body, nil
+	})
+	if err != nil {
+		if err == ctx.Err() {
+			// The context ended while waiting for another request
+			// fetching the same response
+			return nil, newTransportError(method, path, err)
+		}
+		return nil, err
+	}
+	if cached {
+		log.Printf("[DEBUG] sonarcloud: %s %s served from the read cache", method, path)
+	}
+
+	return &http.Response{
+		StatusCode:    expectedResponseCode,
+		Header:        http.Header{},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}, nil
+}
+
+// send sends a request to the endpoint at path, bypassing the read cache.
+func (c *Client) send(ctx context.Context, method string, path string, params url.Values, expectedResponseCode int) (*http.Response, error) {
+	// Join the path onto the base URL, which may point to a server served
+	// under a context path like https://example.com/sonarqube
+	endpoint := c.baseURL
+	endpoint.Path = strings.TrimSuffix(c.baseURL.Path, "/") + "/" + strings.TrimPrefix(path, "/")
+
+	// POST parameters are sent as form body, so secrets like passwords
+	// never show up in a URL that is logged by a proxy or the http client
+	var body interface{} = http.NoBody
+	if method == "POST" {
+		body = []byte(params.Encode())
+	} else {
+		endpoint.RawQuery = params.Encode()
+	}
+
+	// Prepare request
+	req, err := retryablehttp.NewRequestWithContext(ctx, method, endpoint.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if method == "POST" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req = req.WithContext(withIdempotency(req.Context(), method, path))
+
+	// Execute request
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// With the PassthroughErrorHandler a response can come with the
+		// error, e.g. when the context ended before a retry. Its body has
+		// to be closed to release the connection.
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, newTransportError(method, path, err)
+	}
+
+	// Check response code
+	if resp.StatusCode != expectedResponseCode {
+		defer resp.Body.Close()
+
+		// Try to decode the error messages from the body. Not every
+		// error response has a body, so a failure is not reported.
+		errorResponse := ErrorResponse{}
+		_ = json.NewDecoder(resp.Body).Decode(&errorResponse)
+
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			Method:     method,
+			Endpoint:   path,
+			Messages:   errorResponse.Errors,
+		}
+	}
+
+	return resp, nil
+}
+
+// setOptional adds value to params unless it is empty.
+func setOptional(params url.Values, key string, value string) {
+	if value != "" {
+		params.Set(key, value)
+	}
+}
+
+// TokenAuthTransport authenticates every request with a user token. The token
+// is sent as the basic auth login with an empty password, which is what both
+// SonarCloud and SonarQube expect for user tokens.
+type TokenAuthTransport struct {
+	Token string
+	Base  http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *TokenAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the original request
+	authReq := req.Clone(req.Context())
+	authReq.SetBasicAuth(t.Token, "")
+	return t.base().RoundTrip(authReq)
+}
+
+func (t *TokenAuthTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
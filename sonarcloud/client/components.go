@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// ComponentsService handles the api/components web service.
+type ComponentsService struct {
+	client *Client
+}
+
+// Show returns the component with the given key, e.g. a project.
+func (s *ComponentsService) Show(ctx context.Context, component string) (*GetComponent, error) {
+	params := url.Values{
+		"component": []string{component},
+	}
+
+	componentResponse := GetComponent{}
+	err := s.client.call(ctx, "GET", "api/components/show", params, http.StatusOK, &componentResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &componentResponse, nil
+}
@@ -0,0 +1,159 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// credentialsExpiryMargin is how long before its stated expiry a token from
+// a credentials command is replaced, so it does not expire during a request
+const credentialsExpiryMargin = 30 * time.Second
+
+// CommandCredentials is the output a credentials command writes to stdout.
+type CommandCredentials struct {
+	Token string `json:"token"`
+	// ExpiresAt is the time the token expires in RFC 3339 format. Tokens
+	// without an expiry are used until the server rejects them.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CredentialsCommand obtains user tokens from an external command, similar to
+// git credential helpers, so no long-lived credentials have to be configured.
+// The command writes a CommandCredentials JSON object to stdout. The token is
+// cached until it expires or the server rejects it.
+type CredentialsCommand struct {
+	// Command is the executable and its arguments
+	Command []string
+
+	mu          sync.Mutex
+	credentials *CommandCredentials
+}
+
+// Token returns the cached token, running the command when there is none yet
+// or the cached one expired.
+func (c *CredentialsCommand) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.credentials != nil && !c.expired(time.Now()) {
+		return c.credentials.Token, nil
+	}
+	if err := c.run(ctx); err != nil {
+		return "", err
+	}
+	return c.credentials.Token, nil
+}
+
+// Refresh runs the command again after the server rejected token. Concurrent
+// requests rejected with the same token run the command only once.
+func (c *CredentialsCommand) Refresh(ctx context.Context, token string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.credentials != nil && c.credentials.Token != token {
+		return c.credentials.Token, nil
+	}
+	if err := c.run(ctx); err != nil {
+		return "", err
+	}
+	return c.credentials.Token, nil
+}
+
+func (c *CredentialsCommand) expired(now time.Time) bool {
+	expiresAt := c.credentials.ExpiresAt
+	return expiresAt != nil && !now.Add(credentialsExpiryMargin).Before(*expiresAt)
+}
+
+// run executes the command and caches its credentials. c.mu must be held.
+func (c *CredentialsCommand) run(ctx context.Context) error {
+	if len(c.Command) == 0 {
+		return errors.New("The credentials command is empty")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Command[0], c.Command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("Credentials command %s failed: %w: %s", c.Command[0], err, message)
+		}
+		return fmt.Errorf("Credentials command %s failed: %w", c.Command[0], err)
+	}
+
+	credentials := &CommandCredentials{}
+	if err := json.Unmarshal(stdout.Bytes(), credentials); err != nil {
+		// The output is not included, it may contain the token
+		return fmt.Errorf("Unable to decode the output of credentials command %s: %w", c.Command[0], err)
+	}
+	if credentials.Token == "" {
+		return fmt.Errorf("Credentials command %s returned no token", c.Command[0])
+	}
+
+	c.credentials = credentials
+	return nil
+}
+
+// CredentialsCommandTransport authenticates every request with a token from a
+// CredentialsCommand. When the server rejects a token before its stated expiry,
+// e.g. because it was revoked, the command is run again and the request is
+// retried once with the new token.
+type CredentialsCommandTransport struct {
+	Credentials *CredentialsCommand
+	Base        http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *CredentialsCommandTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.Credentials.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.roundTrip(req, token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	// The token was rejected before its stated expiry, as expired tokens
+	// are never sent. Retry with a new one if the body can be sent again.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	token, err = t.Credentials.Refresh(req.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+
+	retryReq := req.Clone(req.Context())
+	if req.GetBody != nil {
+		retryReq.Body, err = req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t.roundTrip(retryReq, token)
+}
+
+func (t *CredentialsCommandTransport) roundTrip(req *http.Request, token string) (*http.Response, error) {
+	// RoundTrippers must not modify the original request
+	authReq := req.Clone(req.Context())
+	authReq.SetBasicAuth(token, "")
+	return t.base().RoundTrip(authReq)
+}
+
+func (t *CredentialsCommandTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
@@ -0,0 +1,297 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/meetdpv/SonarCloud/tests/sonarcloudtest"
+)
+
+// TestCredentialsCommandHelper is the credentials command of the tests, run
+// through the test binary. It prints the file SONARCLOUD_TEST_CREDENTIALS,
+// or fails with the message of a file starting with "fail:", and counts its
+// runs in the file next to it.
+func TestCredentialsCommandHelper(t *testing.T) {
+	path := os.Getenv("SONARCLOUD_TEST_CREDENTIALS")
+	if path == "" {
+		t.Skip("only runs as the credentials command of the tests")
+	}
+
+	f, err := os.OpenFile(path+".runs", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err == nil {
+		f.WriteString("run\n")
+		f.Close()
+	}
+
+	output, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprint(os.Stderr, err)
+		os.Exit(2)
+	}
+	if message := strings.TrimPrefix(string(output), "fail:"); message != string(output) {
+		fmt.Fprint(os.Stderr, message)
+		os.Exit(1)
+	}
+	fmt.Print(string(output))
+	os.Exit(0)
+}
+
+// testCredentials is the output of the credentials command of a test.
+type testCredentials struct {
+	t    *testing.T
+	path string
+}
+
+// newTestCredentials returns a credentials command that prints output.
+func newTestCredentials(t *testing.T, output string) (*testCredentials, *CredentialsCommand) {
+	t.Helper()
+	c := &testCredentials{t: t, path: filepath.Join(t.TempDir(), "credentials.json")}
+	c.set(output)
+	t.Setenv("SONARCLOUD_TEST_CREDENTIALS", c.path)
+	return c, &CredentialsCommand{Command: []string{os.Args[0], "-test.run=^TestCredentialsCommandHelper$"}}
+}
+
+// set changes the output of the command.
+func (c *testCredentials) set(output string) {
+	c.t.Helper()
+	if err := ioutil.WriteFile(c.path, []byte(output), 0600); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// runs returns how often the command ran.
+func (c *testCredentials) runs() int {
+	c.t.Helper()
+	data, err := ioutil.ReadFile(c.path + ".runs")
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return strings.Count(string(data), "\n")
+}
+
+func TestCredentialsCommandToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		token  string
+		// runs is how often the command runs for two tokens
+		runs int
+		err  string
+	}{
+		{
+			name:   "without expiry",
+			output: `{"token": "squ_1"}`,
+			token:  "squ_1",
+			runs:   1,
+		},
+		{
+			name:   "valid",
+			output: fmt.Sprintf(`{"token": "squ_1", "expires_at": %q}`, time.Now().Add(time.Hour).Format(time.RFC3339)),
+			token:  "squ_1",
+			runs:   1,
+		},
+		{
+			name:   "about to expire",
+			output: fmt.Sprintf(`{"token": "squ_1", "expires_at": %q}`, time.Now().Add(credentialsExpiryMargin/2).Format(time.RFC3339)),
+			token:  "squ_1",
+			runs:   2,
+		},
+		{
+			name:   "expired",
+			output: `{"token": "squ_1", "expires_at": "2020-01-31T12:00:00Z"}`,
+			token:  "squ_1",
+			runs:   2,
+		},
+		{
+			name:   "invalid expiry",
+			output: `{"token": "squ_secret", "expires_at": "tomorrow"}`,
+			runs:   1,
+			err:    "Unable to decode the output",
+		},
+		{
+			name:   "no JSON",
+			output: `squ_secret`,
+			runs:   1,
+			err:    "Unable to decode the output",
+		},
+		{
+			name:   "no token",
+			output: `{"expires_at": "2099-01-31T12:00:00Z"}`,
+			runs:   1,
+			err:    "returned no token",
+		},
+		{
+			name:   "failure",
+			output: `fail:vault is sealed`,
+			runs:   1,
+			err:    "failed: exit status 1: vault is sealed",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, credentials := newTestCredentials(t, test.output)
+
+			token, err := credentials.Token(context.Background())
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected an error containing %q, got %v", test.err, err)
+				}
+				// The output may contain the token
+				if strings.Contains(err.Error(), "squ_secret") {
+					t.Errorf("the error contains the output of the command: %v", err)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if token != test.token {
+					t.Errorf("expected the token %q, got %q", test.token, token)
+				}
+				if _, err := credentials.Token(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if runs := output.runs(); runs != test.runs {
+				t.Errorf("expected the command to run %d times, it ran %d times", test.runs, runs)
+			}
+		})
+	}
+}
+
+func TestCredentialsCommandRefresh(t *testing.T) {
+	output, credentials := newTestCredentials(t, `{"token": "squ_1"}`)
+	ctx := context.Background()
+
+	if _, err := credentials.Token(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Requests rejected with the same token run the command once
+	output.set(`{"token": "squ_2"}`)
+	for i := 0; i < 2; i++ {
+		token, err := credentials.Refresh(ctx, "squ_1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "squ_2" {
+			t.Errorf("expected the token squ_2, got %q", token)
+		}
+	}
+	if runs := output.runs(); runs != 2 {
+		t.Errorf("expected the command to run 2 times, it ran %d times", runs)
+	}
+}
+
+// countingTransport counts the requests and the rejected ones.
+type countingTransport struct {
+	requests     int32
+	unauthorized int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.requests, 1)
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		atomic.AddInt32(&t.unauthorized, 1)
+	}
+	return resp, err
+}
+
+func TestCredentialsCommandTransport(t *testing.T) {
+	server := sonarcloudtest.NewServer(sonarcloudtest.Options{Token: "squ_1"})
+	defer server.Close()
+	output, credentials := newTestCredentials(t, `{"token": "squ_1"}`)
+
+	counter := &countingTransport{}
+	httpClient := retryablehttp.NewClient()
+	httpClient.Logger = nil
+	httpClient.RetryMax = 0
+	httpClient.HTTPClient.Transport = &CredentialsCommandTransport{
+		Credentials: credentials,
+		Base:        counter,
+	}
+	sonarcloudClient := NewClient(httpClient, url.URL{Scheme: server.Scheme(), Host: server.Host()})
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		// token is the token the server accepts, output what the command
+		// prints from then on
+		token  string
+		output string
+		// requests is how many requests reach the server, runs how often
+		// the command ran in total
+		requests     int32
+		unauthorized int32
+		runs         int
+		err          bool
+	}{
+		{
+			name:     "valid",
+			token:    "squ_1",
+			output:   `{"token": "squ_1"}`,
+			requests: 1,
+			runs:     1,
+		},
+		{
+			name:         "revoked",
+			token:        "squ_2",
+			output:       `{"token": "squ_2"}`,
+			requests:     2,
+			unauthorized: 1,
+			runs:         2,
+		},
+		{
+			name:         "rejected again",
+			token:        "squ_3",
+			output:       `{"token": "squ_4"}`,
+			requests:     2,
+			unauthorized: 2,
+			runs:         3,
+			err:          true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server.SetToken(test.token)
+			output.set(test.output)
+			counter.requests, counter.unauthorized = 0, 0
+
+			// The form body of the POST is sent again on the retry
+			_, err := sonarcloudClient.Projects.Create(ctx, CreateProjectOptions{
+				Name:    test.name,
+				Project: strings.ReplaceAll(test.name, " ", "-"),
+			})
+			var apiErr *APIError
+			if test.err {
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+					t.Fatalf("expected a 401 API error, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if counter.requests != test.requests || counter.unauthorized != test.unauthorized {
+				t.Errorf("expected %d requests of which %d were rejected, got %d and %d", test.requests, test.unauthorized, counter.requests, counter.unauthorized)
+			}
+			if runs := output.runs(); runs != test.runs {
+				t.Errorf("expected the command to run %d times, it ran %d times", test.runs, runs)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// APIError is returned when the API responds with an unexpected status code.
+// Use errors.As to inspect it, e.g. to treat a 404 as a deleted object.
+type APIError struct {
+	StatusCode int
+	Method     string
+	// Endpoint is the path of the request. It never contains credentials
+	// or query parameters.
+	Endpoint string
+	// Messages are all error messages of the response body. It is empty
+	// when the body did not contain any.
+	Messages []ErrorMessage
+}
+
+func (e *APIError) Error() string {
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("%s %s returned status code %d", e.Method, e.Endpoint, e.StatusCode)
+	}
+
+	messages := make([]string, 0, len(e.Messages))
+	for _, message := range e.Messages {
+		messages = append(messages, message.Message)
+	}
+	return fmt.Sprintf("%s %s returned status code %d: %s", e.Method, e.Endpoint, e.StatusCode, strings.Join(messages, "; "))
+}
+
+// TransportError is returned when no response was received from the API, e.g.
+// because the host is unreachable or the request timed out.
+type TransportError struct {
+	Method string
+	// Endpoint is the path of the request. It never contains credentials
+	// or query parameters.
+	Endpoint string
+	Err      error
+}
+
+// newTransportError wraps err of a failed request. The *url.Error added by the
+// http client is dropped, as its URL contains the query parameters.
+func newTransportError(method string, endpoint string, err error) *TransportError {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+
+	return &TransportError{
+		Method:   method,
+		Endpoint: endpoint,
+		Err:      err,
+	}
+}
+
+func (e *TransportError) Error() string {
+	if e.Timeout() {
+		return fmt.Sprintf("%s %s timed out: the SonarCloud API did not respond before the deadline", e.Method, e.Endpoint)
+	}
+	return fmt.Sprintf("%s %s failed: %v", e.Method, e.Endpoint, e.Err)
+}
+
+// Timeout reports whether the request did not complete before the deadline of
+// its context, e.g. the timeout of a resource operation.
+func (e *TransportError) Timeout() bool {
+	return errors.Is(e.Err, context.DeadlineExceeded)
+}
+
+// Unwrap returns the underlying error.
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ConcurrencyLimitTransport limits the number of requests that are in flight
+// at the same time. All resources share the provider's client, so the limit
+// holds no matter how many resources Terraform applies in parallel. Requests
+// over the limit wait for a free slot, the time they waited is logged at DEBUG
+// level. A slot is held until the response body is closed.
+type ConcurrencyLimitTransport struct {
+	base  http.RoundTripper
+	slots chan struct{}
+}
+
+// NewConcurrencyLimitTransport returns a transport that sends at most limit
+// requests through base at the same time.
+func NewConcurrencyLimitTransport(limit int, base http.RoundTripper) *ConcurrencyLimitTransport {
+	return &ConcurrencyLimitTransport{
+		base:  base,
+		slots: make(chan struct{}, limit),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *ConcurrencyLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	select {
+	case t.slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	log.Printf("[DEBUG] sonarcloud: %s %s queued for %s (%d of %d requests in flight)",
+		req.Method, req.URL.Path, time.Since(start).Round(time.Millisecond), len(t.slots), cap(t.slots))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: t.release}
+	return resp, nil
+}
+
+func (t *ConcurrencyLimitTransport) release() {
+	<-t.slots
+}
+
+// releasingBody releases the slot of its request when it is closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
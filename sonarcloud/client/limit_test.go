@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTripFunc is a transport answering with a function.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// okTransport answers every request with an empty 200 response.
+var okTransport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+})
+
+func TestConcurrencyLimitTransport(t *testing.T) {
+	newRequest := func(ctx context.Context) *http.Request {
+		req, err := http.NewRequestWithContext(ctx, "GET", "https://sonarcloud.io/api/projects/search", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name string
+		// wait is run while the first request holds the only slot, with
+		// a request that has to wait for it
+		wait func(t *testing.T, transport *ConcurrencyLimitTransport, req func(ctx context.Context) *http.Request)
+	}{
+		{
+			name: "cancelled while waiting",
+			wait: func(t *testing.T, transport *ConcurrencyLimitTransport, req func(ctx context.Context) *http.Request) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(10*time.Millisecond, cancel)
+				if _, err := transport.RoundTrip(req(ctx)); !errors.Is(err, context.Canceled) {
+					t.Fatalf("expected the context error, got %v", err)
+				}
+			},
+		},
+		{
+			name: "deadline while waiting",
+			wait: func(t *testing.T, transport *ConcurrencyLimitTransport, req func(ctx context.Context) *http.Request) {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+				defer cancel()
+				if _, err := transport.RoundTrip(req(ctx)); !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("expected the context error, got %v", err)
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transport := NewConcurrencyLimitTransport(1, okTransport)
+
+			resp, err := transport.RoundTrip(newRequest(context.Background()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.wait(t, transport, newRequest)
+
+			// The waiting request took no slot, so closing the body of
+			// the first one frees the only slot
+			resp.Body.Close()
+			resp.Body.Close()
+			if len(transport.slots) != 0 {
+				t.Fatalf("expected no slot in use, got %d", len(transport.slots))
+			}
+			resp, err = transport.RoundTrip(newRequest(context.Background()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+		})
+	}
+}
+
+func TestConcurrencyLimitTransportError(t *testing.T) {
+	failing := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+	transport := NewConcurrencyLimitTransport(1, failing)
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("GET", "https://sonarcloud.io/api/projects/search", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := transport.RoundTrip(req); err == nil {
+			t.Fatal("expected the error of the transport")
+		}
+	}
+	if len(transport.slots) != 0 {
+		t.Errorf("expected the failed requests to release their slot, %d in use", len(transport.slots))
+	}
+}
+
+func TestConcurrencyLimitTransportLimit(t *testing.T) {
+	const limit = 3
+	release := make(chan struct{})
+	inFlight := make(chan struct{}, 2*limit)
+	blocking := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		inFlight <- struct{}{}
+		<-release
+		return okTransport(req)
+	})
+	transport := NewConcurrencyLimitTransport(limit, blocking)
+
+	done := make(chan error, 2*limit)
+	for i := 0; i < 2*limit; i++ {
+		go func() {
+			req, err := http.NewRequest("GET", "https://sonarcloud.io/api/projects/search", nil)
+			if err != nil {
+				done <- err
+				return
+			}
+			resp, err := transport.RoundTrip(req)
+			if err == nil {
+				resp.Body.Close()
+			}
+			done <- err
+		}()
+	}
+
+	for i := 0; i < limit; i++ {
+		<-inFlight
+	}
+	select {
+	case <-inFlight:
+		t.Fatalf("expected at most %d requests in flight", limit)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	for i := 0; i < 2*limit; i++ {
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
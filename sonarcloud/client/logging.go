@@ -0,0 +1,79 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+)
+
+// maxLoggedBodySize is the number of bytes of a body that is logged at most
+const maxLoggedBodySize = 4096
+
+// LoggingTransport logs every request through the standard logger with the
+// level prefixes Terraform understands. The method, path, status and latency
+// are logged at DEBUG level, the request and response bodies truncated at
+// TRACE level. Credentials are always redacted: the URL userinfo and headers
+// are never logged, password and token fields are replaced, as are all
+// Secrets wherever they appear.
+type LoggingTransport struct {
+	Base http.RoundTripper
+	// Secrets are redacted wherever they appear, e.g. the configured token
+	// or password
+	Secrets []string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s := scrubber{secrets: t.Secrets}
+	endpoint := req.URL.Path
+	if req.URL.RawQuery != "" {
+		endpoint += "?" + s.form(req.URL.RawQuery)
+	}
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if body != "" {
+		log.Printf("[TRACE] sonarcloud: %s %s request body: %s", req.Method, endpoint, truncate(s.form(body)))
+	}
+
+	start := time.Now()
+	resp, err := t.base().RoundTrip(req)
+	latency := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		log.Printf("[DEBUG] sonarcloud: %s %s failed after %s: %s", req.Method, endpoint, latency, s.replaceSecrets(err.Error()))
+		return nil, err
+	}
+	log.Printf("[DEBUG] sonarcloud: %s %s returned %d in %s", req.Method, endpoint, resp.StatusCode, latency)
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	if len(respBody) > 0 {
+		log.Printf("[TRACE] sonarcloud: %s %s response body: %s", req.Method, endpoint, truncate(s.json(string(respBody))))
+	}
+
+	return resp, nil
+}
+
+func (t *LoggingTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// truncate shortens body to maxLoggedBodySize bytes.
+func truncate(body string) string {
+	if len(body) <= maxLoggedBodySize {
+		return body
+	}
+	return fmt.Sprintf("%s... (%d more bytes)", body[:maxLoggedBodySize], len(body)-maxLoggedBodySize)
+}
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoggingTransport(t *testing.T) {
+	// The server answers with the body of the test case
+	var responseBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(responseBody))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	transport := &LoggingTransport{Secrets: []string{"squ_configured", "provider-password"}}
+	// Only the redacted secret is logged when a body is truncated
+	padding := strings.Repeat("a", maxLoggedBodySize-len("squ_con"))
+
+	tests := []struct {
+		name     string
+		method   string
+		userinfo *url.Userinfo
+		path     string
+		query    string
+		header   http.Header
+		body     string
+		response string
+		// contains must be logged, secrets never
+		contains []string
+		secrets  []string
+	}{
+		{
+			name:     "url userinfo",
+			method:   "GET",
+			userinfo: url.UserPassword("admin", "admin-password"),
+			path:     "/api/users/search",
+			query:    "q=jdoe",
+			contains: []string{"[DEBUG] sonarcloud: GET /api/users/search?q=jdoe returned 200 in "},
+			secrets:  []string{"admin-password", "admin:"},
+		},
+		{
+			name:     "authorization header",
+			method:   "GET",
+			path:     "/api/projects/search",
+			header:   http.Header{"Authorization": []string{"Basic c3F1X2hlYWRlcjo="}},
+			contains: []string{"[DEBUG] sonarcloud: GET /api/projects/search returned 200"},
+			secrets:  []string{"c3F1X2hlYWRlcjo", "Authorization", "Basic"},
+		},
+		{
+			name:     "form passwords",
+			method:   "POST",
+			path:     "/api/users/change_password",
+			body:     "login=jdoe&password=new-password&previousPassword=old-password",
+			contains: []string{"[TRACE] sonarcloud: POST /api/users/change_password request body: login=jdoe&password=REDACTED&previousPassword=REDACTED"},
+			secrets:  []string{"new-password", "old-password"},
+		},
+		{
+			name:     "query password",
+			method:   "POST",
+			path:     "/api/users/create",
+			query:    "login=jdoe&password=user-password",
+			contains: []string{"POST /api/users/create?login=jdoe&password=REDACTED returned 200"},
+			secrets:  []string{"user-password"},
+		},
+		{
+			name:     "generated token",
+			method:   "POST",
+			path:     "/api/user_tokens/generate",
+			body:     "login=jdoe&name=ci",
+			response: `{"login":"jdoe","name":"ci","token":"squ_generated"}`,
+			contains: []string{`response body: {"login":"jdoe","name":"ci","token":"REDACTED"}`},
+			secrets:  []string{"squ_generated"},
+		},
+		{
+			name:     "configured secrets in bodies",
+			method:   "POST",
+			path:     "/api/user_groups/create",
+			body:     "name=ci&description=" + url.QueryEscape("provider-password squ_configured"),
+			response: `{"errors":[{"msg":"squ_configured and provider-password are not allowed"}]}`,
+			contains: []string{"request body: description=REDACTED+REDACTED&name=ci", `response body: {"errors":[{"msg":"REDACTED and REDACTED are not allowed"}]}`},
+			secrets:  []string{"squ_configured", "provider-password", "provider-password"},
+		},
+		{
+			name:     "truncated request body",
+			method:   "POST",
+			path:     "/api/user_groups/create",
+			body:     "name=" + padding + "squ_configured",
+			contains: []string{"request body: name=" + padding + "RE... (6 more bytes)"},
+			secrets:  []string{"squ_con"},
+		},
+		{
+			name:     "truncated response body",
+			method:   "GET",
+			path:     "/api/server/version",
+			response: padding + "squ_configured",
+			contains: []string{"response body: " + padding + "REDACTE... (1 more bytes)"},
+			secrets:  []string{"squ_con"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logged.Reset()
+			responseBody = test.response
+
+			requestURL := *serverURL
+			requestURL.User = test.userinfo
+			requestURL.Path = test.path
+			requestURL.RawQuery = test.query
+			req, err := http.NewRequest(test.method, requestURL.String(), strings.NewReader(test.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key, values := range test.header {
+				req.Header[key] = values
+			}
+
+			resp, err := transport.RoundTrip(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The body is still there for the caller
+			body, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != test.response {
+				t.Errorf("expected the response body %q, got %q", test.response, body)
+			}
+
+			output := logged.String()
+			for _, s := range test.contains {
+				if !strings.Contains(output, s) {
+					t.Errorf("expected the log to contain %q, got:\n%s", s, output)
+				}
+			}
+			for _, secret := range test.secrets {
+				if strings.Contains(output, secret) {
+					t.Errorf("the log contains %q:\n%s", secret, output)
+				}
+			}
+		})
+	}
+}
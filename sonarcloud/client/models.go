@@ -1,4 +1,4 @@
-package sonarcloud
+package client
 
 /*
  * Make sure the fields are public (First letter Uppercase),
@@ -29,11 +29,34 @@ type GetQualityGate struct {
 	Actions    QualityGateActions                   `json:"actions"`
 }
 
-// GetQualityProfile for unmarshalling response body of quality profile get
-type GetQualityProfile struct {
-        Name           string                               `json:"name"`
-        Organization   string                               `json:"organization"`
-        Language       string                               `json:"language"`
+// GetQualityGates for unmarshalling response body of quality gate list
+type GetQualityGates struct {
+	QualityGates []QualityGate `json:"qualitygates"`
+}
+
+// QualityGate used in GetQualityGates
+type QualityGate struct {
+	ID        int64              `json:"id"`
+	Name      string             `json:"name"`
+	IsDefault bool               `json:"isDefault"`
+	IsBuiltIn bool               `json:"isBuiltIn"`
+	Actions   QualityGateActions `json:"actions"`
+}
+
+// GetQualityProfiles for unmarshalling response body of quality profile search
+type GetQualityProfiles struct {
+	Profiles []QualityProfile `json:"profiles"`
+}
+
+// QualityProfile used in GetQualityProfiles and CreateQualityProfileResponse,
+// and returned by a quality profile copy
+type QualityProfile struct {
+	Key          string `json:"key"`
+	Name         string `json:"name"`
+	Organization string `json:"organization"`
+	Language     string `json:"language"`
+	IsDefault    bool   `json:"isDefault"`
+	IsBuiltIn    bool   `json:"isBuiltIn"`
 }
 
 // QualityGateActions used in GetQualityGate
@@ -54,8 +77,7 @@ type CreateQualityGateResponse struct {
 
 // CreateQualityProfileResponse for unmarshalling response body of quality profile creation
 type CreateQualityProfileResponse struct {
-        ID   int64  `json:"id"`
-        Name string `json:"name"`
+	Profile QualityProfile `json:"profile"`
 }
 
 // CreateQualityGateConditionResponse for unmarshalling response body of condition creation
@@ -79,6 +101,52 @@ type Project struct {
 	Qualifier string `json:"qualifier"`
 }
 
+// GetProjectTags for unmarshalling response body of project tag search
+type GetProjectTags struct {
+	Tags []string `json:"tags"`
+	// Paging is only returned by servers that report the total number of
+	// tags
+	Paging *Paging `json:"paging,omitempty"`
+	// Truncated is set when the server reports more tags than it returned
+	Truncated bool `json:"-"`
+}
+
+// GetNewCodePeriod for unmarshalling response body of new code period show
+type GetNewCodePeriod struct {
+	ProjectKey string `json:"projectKey"`
+	BranchKey  string `json:"branchKey"`
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	Inherited  bool   `json:"inherited"`
+}
+
+// GetSettings for unmarshalling response body of settings values
+type GetSettings struct {
+	Settings []Setting `json:"settings"`
+}
+
+// Setting used in GetSettings
+type Setting struct {
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+	Inherited bool   `json:"inherited"`
+}
+
+// GetComponent for unmarshalling response body of component show
+type GetComponent struct {
+	Component Component `json:"component"`
+}
+
+// Component used in GetComponent
+type Component struct {
+	Organization string   `json:"organization"`
+	Key          string   `json:"key"`
+	Name         string   `json:"name"`
+	Qualifier    string   `json:"qualifier"`
+	Visibility   string   `json:"visibility"`
+	Tags         []string `json:"tags"`
+}
+
 // GetProject for unmarshalling response body from getting project details
 type GetProject struct {
 	Paging     Paging              `json:"paging"`
@@ -114,9 +182,10 @@ type GetTokens struct {
 
 // Token struct
 type Token struct {
-	Login string `json:"login,omitempty"`
-	Name  string `json:"name,omitempty"`
-	Token string `json:"token,omitempty"`
+	Login          string `json:"login,omitempty"`
+	Name           string `json:"name,omitempty"`
+	Token          string `json:"token,omitempty"`
+	ExpirationDate string `json:"expirationDate,omitempty"`
 }
 
 // CreateGroupResponse for unmarshalling response body of group creation
@@ -208,7 +277,15 @@ type GetInstalledPlugins struct {
 	Plugins []Plugin `json:"plugins"`
 }
 
-// Plugin used in GetInstalledPlugins
+// GetPendingPlugins for unmarshalling response body from getting the plugins
+// that are installed, updated or removed when the server restarts
+type GetPendingPlugins struct {
+	Installing []Plugin `json:"installing"`
+	Updating   []Plugin `json:"updating"`
+	Removing   []Plugin `json:"removing"`
+}
+
+// Plugin used in GetInstalledPlugins and GetPendingPlugins
 type Plugin struct {
 	Key                string `json:"key"`
 	Name               string `json:"name"`
@@ -226,3 +303,13 @@ type Plugin struct {
 	DocumentationPath  bool   `json:"documentationPath"`
 	UpdatedAt          int    `json:"updatedAt"`
 }
+
+// GlobalNavigation for unmarshalling response body of the global navigation
+type GlobalNavigation struct {
+	Version string `json:"version"`
+	// Edition is only returned by SonarQube, e.g. "community" or "enterprise"
+	Edition string `json:"edition"`
+	// Settings are public settings of the server, SonarCloud sets
+	// sonar.sonarcloud.enabled
+	Settings map[string]string `json:"settings"`
+}
@@ -0,0 +1,22 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// NavigationService handles the api/navigation web service.
+type NavigationService struct {
+	client *Client
+}
+
+// Global returns the global information about the server, like its edition.
+func (s *NavigationService) Global(ctx context.Context) (*GlobalNavigation, error) {
+	globalNavigation := GlobalNavigation{}
+	err := s.client.call(ctx, "GET", "api/navigation/global", url.Values{}, http.StatusOK, &globalNavigation)
+	if err != nil {
+		return nil, err
+	}
+	return &globalNavigation, nil
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// NewCodePeriodsService handles the api/new_code_periods web service.
+type NewCodePeriodsService struct {
+	client *Client
+}
+
+// NewCodePeriodOptions select the scope of a new code definition: a project
+// branch, a project, or the default of the organization when neither is set.
+// On SonarQube the default is global and no organization is set.
+type NewCodePeriodOptions struct {
+	Project      string
+	Branch       string
+	Organization string
+	// Type is PREVIOUS_VERSION, NUMBER_OF_DAYS or REFERENCE_BRANCH
+	Type string
+	// Value is the number of days or the reference branch
+	Value string
+}
+
+func (opt NewCodePeriodOptions) values() url.Values {
+	params := url.Values{}
+	setOptional(params, "project", opt.Project)
+	setOptional(params, "branch", opt.Branch)
+	setOptional(params, "organization", opt.Organization)
+	return params
+}
+
+// Set sets the new code definition of the scope.
+func (s *NewCodePeriodsService) Set(ctx context.Context, opt NewCodePeriodOptions) error {
+	params := opt.values()
+	params.Set("type", opt.Type)
+	setOptional(params, "value", opt.Value)
+
+	return s.client.call(ctx, "POST", "api/new_code_periods/set", params, http.StatusNoContent, nil)
+}
+
+// Show returns the new code definition of the scope. Type and Value of opt
+// are ignored.
+func (s *NewCodePeriodsService) Show(ctx context.Context, opt NewCodePeriodOptions) (*GetNewCodePeriod, error) {
+	newCodePeriod := GetNewCodePeriod{}
+	err := s.client.call(ctx, "GET", "api/new_code_periods/show", opt.values(), http.StatusOK, &newCodePeriod)
+	if err != nil {
+		return nil, err
+	}
+	return &newCodePeriod, nil
+}
+
+// Unset removes the new code definition of the scope, so it inherits the one
+// of the enclosing scope again. Type and Value of opt are ignored.
+func (s *NewCodePeriodsService) Unset(ctx context.Context, opt NewCodePeriodOptions) error {
+	return s.client.call(ctx, "POST", "api/new_code_periods/unset", opt.values(), http.StatusNoContent, nil)
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+// pageSize is the number of results requested per page. It is the largest
+// page size every search endpoint accepts.
+const pageSize = 100
+
+// paginated is implemented by the responses of search endpoints that split
+// their results into pages.
+type paginated interface {
+	// paging returns the paging information of the response.
+	paging() Paging
+	// results returns the number of results in the response.
+	results() int
+	// merge appends the results of another page to the response.
+	merge(page paginated)
+}
+
+// paginate walks the pages of the search endpoint at path until Paging.Total
+// results have been received, and merges all of them into v.
+func (c *Client) paginate(ctx context.Context, path string, params url.Values, v paginated) error {
+	params.Set("ps", strconv.Itoa(pageSize))
+
+	received := 0
+	for pageIndex := 1; ; pageIndex++ {
+		params.Set("p", strconv.Itoa(pageIndex))
+
+		// The first page is decoded into v directly, later pages
+		// into a new value of the same type which is merged into v.
+		page := v
+		if pageIndex > 1 {
+			page = reflect.New(reflect.TypeOf(v).Elem()).Interface().(paginated)
+		}
+
+		err := c.call(ctx, "GET", path, params, http.StatusOK, page)
+		if err != nil {
+			return err
+		}
+
+		if pageIndex > 1 {
+			v.merge(page)
+		}
+
+		// Stop on an empty page as well, so an endpoint that reports a
+		// wrong total can not keep us looping forever.
+		received += page.results()
+		if page.results() == 0 || int64(received) >= page.paging().Total {
+			return nil
+		}
+	}
+}
+
+func (p *GetProject) paging() Paging { return p.Paging }
+func (p *GetProject) results() int   { return len(p.Components) }
+func (p *GetProject) merge(page paginated) {
+	p.Components = append(p.Components, page.(*GetProject).Components...)
+}
+
+func (u *GetUser) paging() Paging { return u.Paging }
+func (u *GetUser) results() int   { return len(u.Users) }
+func (u *GetUser) merge(page paginated) {
+	u.Users = append(u.Users, page.(*GetUser).Users...)
+}
+
+func (g *GetGroup) paging() Paging { return g.Paging }
+func (g *GetGroup) results() int   { return len(g.Groups) }
+func (g *GetGroup) merge(page paginated) {
+	g.Groups = append(g.Groups, page.(*GetGroup).Groups...)
+}
+
+func (g *GetGroupPermissions) paging() Paging { return g.Paging }
+func (g *GetGroupPermissions) results() int   { return len(g.Groups) }
+func (g *GetGroupPermissions) merge(page paginated) {
+	g.Groups = append(g.Groups, page.(*GetGroupPermissions).Groups...)
+}
+
+func (t *GetPermissionTemplates) paging() Paging { return t.Paging }
+func (t *GetPermissionTemplates) results() int   { return len(t.PermissionTemplates) }
+func (t *GetPermissionTemplates) merge(page paginated) {
+	t.PermissionTemplates = append(t.PermissionTemplates, page.(*GetPermissionTemplates).PermissionTemplates...)
+}
+
+func (a *GetQualityGateAssociation) paging() Paging { return a.Paging }
+func (a *GetQualityGateAssociation) results() int   { return len(a.Results) }
+func (a *GetQualityGateAssociation) merge(page paginated) {
+	a.Results = append(a.Results, page.(*GetQualityGateAssociation).Results...)
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name string
+		// pages are the number of results the server returns per page,
+		// total the total it reports
+		pages []int
+		total int64
+		// requests is the number of pages requested
+		requests int
+	}{
+		{name: "no results", pages: []int{0}, total: 0, requests: 1},
+		{name: "single page", pages: []int{3}, total: 3, requests: 1},
+		{name: "last page", pages: []int{100, 100, 50}, total: 250, requests: 3},
+		{name: "full last page", pages: []int{100, 100}, total: 200, requests: 2},
+		{name: "empty page", pages: []int{100, 20, 0, 100}, total: 500, requests: 3},
+		{name: "empty first page", pages: []int{0, 100}, total: 100, requests: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				query := r.URL.Query()
+				if query.Get("q") != "jdoe" || query.Get("ps") != strconv.Itoa(pageSize) {
+					t.Errorf("expected the parameters of the search on every page, got %s", r.URL.RawQuery)
+				}
+				page, err := strconv.Atoi(query.Get("p"))
+				if err != nil || page != requests {
+					t.Errorf("expected page %d, got %q", requests, query.Get("p"))
+				}
+				if page < 1 || page > len(test.pages) {
+					t.Errorf("page %d is out of range", page)
+					http.NotFound(w, r)
+					return
+				}
+
+				response := GetUser{Paging: Paging{PageIndex: int64(page), PageSize: pageSize, Total: test.total}, Users: []User{}}
+				for i := 0; i < test.pages[page-1]; i++ {
+					response.Users = append(response.Users, User{Login: fmt.Sprintf("user-%d-%d", page, i)})
+				}
+				json.NewEncoder(w).Encode(response)
+			}))
+			defer server.Close()
+			serverURL, err := url.Parse(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			httpClient := retryablehttp.NewClient()
+			httpClient.Logger = nil
+			client := NewClient(httpClient, *serverURL)
+
+			users, err := client.Users.Search(context.Background(), "jdoe")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if requests != test.requests {
+				t.Errorf("expected %d requests, got %d", test.requests, requests)
+			}
+			// The pages are merged in order
+			var want []string
+			for page, results := range test.pages[:test.requests] {
+				for i := 0; i < results; i++ {
+					want = append(want, fmt.Sprintf("user-%d-%d", page+1, i))
+				}
+			}
+			if len(users.Users) != len(want) {
+				t.Fatalf("expected %d users, got %d", len(want), len(users.Users))
+			}
+			for i, user := range users.Users {
+				if user.Login != want[i] {
+					t.Errorf("expected user %d to be %s, got %s", i, want[i], user.Login)
+				}
+			}
+		})
+	}
+}
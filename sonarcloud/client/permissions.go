@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// PermissionsService handles the api/permissions web service.
+type PermissionsService struct {
+	client *Client
+}
+
+// PermissionOptions select the user or group, and optionally the project or
+// permission template, a permission is granted to or revoked from.
+type PermissionOptions struct {
+	Login        string
+	GroupName    string
+	ProjectKey   string
+	TemplateID   string
+	Organization string
+	Permission   string
+}
+
+func (opt PermissionOptions) values() url.Values {
+	params := url.Values{
+		"permission": []string{opt.Permission},
+	}
+	setOptional(params, "login", opt.Login)
+	setOptional(params, "groupName", opt.GroupName)
+	setOptional(params, "projectKey", opt.ProjectKey)
+	setOptional(params, "templateId", opt.TemplateID)
+	setOptional(params, "organization", opt.Organization)
+	return params
+}
+
+// AddUser grants a permission to a user.
+func (s *PermissionsService) AddUser(ctx context.Context, opt PermissionOptions) error {
+	return s.client.call(ctx, "POST", "api/permissions/add_user", opt.values(), http.StatusNoContent, nil)
+}
+
+// AddGroup grants a permission to a group.
+func (s *PermissionsService) AddGroup(ctx context.Context, opt PermissionOptions) error {
+	return s.client.call(ctx, "POST", "api/permissions/add_group", opt.values(), http.StatusNoContent, nil)
+}
+
+// AddUserToTemplate adds a user permission to a permission template.
+func (s *PermissionsService) AddUserToTemplate(ctx context.Context, opt PermissionOptions) error {
+	return s.client.call(ctx, "POST", "api/permissions/add_user_to_template", opt.values(), http.StatusNoContent, nil)
+}
+
+// AddGroupToTemplate adds a group permission to a permission template.
+func (s *PermissionsService) AddGroupToTemplate(ctx context.Context, opt PermissionOptions) error {
+	return s.client.call(ctx, "POST", "api/permissions/add_group_to_template", opt.values(), http.StatusNoContent, nil)
+}
+
+// RemoveUser revokes a permission from a user.
+func (s *PermissionsService) RemoveUser(ctx context.Context, opt PermissionOptions) error {
+	return s.client.call(ctx, "POST", "api/permissions/remove_user", opt.values(), http.StatusNoContent, nil)
+}
+
+// RemoveGroup revokes a permission from a group.
+func (s *PermissionsService) RemoveGroup(ctx context.Context, opt PermissionOptions) error {
+	return s.client.call(ctx, "POST", "api/permissions/remove_group", opt.values(), http.StatusNoContent, nil)
+}
+
+// RemoveUserFromTemplate removes a user permission from a permission template.
+func (s *PermissionsService) RemoveUserFromTemplate(ctx context.Context, opt PermissionOptions) error {
+	return s.client.call(ctx, "POST", "api/permissions/remove_user_from_template", opt.values(), http.StatusNoContent, nil)
+}
+
+// RemoveGroupFromTemplate removes a group permission from a permission template.
+func (s *PermissionsService) RemoveGroupFromTemplate(ctx context.Context, opt PermissionOptions) error {
+	return s.client.call(ctx, "POST", "api/permissions/remove_group_from_template", opt.values(), http.StatusNoContent, nil)
+}
+
+// SearchPermissionsOptions are the parameters of the permission search
+// endpoints. The endpoints are paginated, the methods using these options
+// request every result page.
+type SearchPermissionsOptions struct {
+	ProjectKey   string
+	TemplateID   string
+	Organization string
+}
+
+func (opt SearchPermissionsOptions) values() url.Values {
+	params := url.Values{}
+	setOptional(params, "projectKey", opt.ProjectKey)
+	setOptional(params, "templateId", opt.TemplateID)
+	setOptional(params, "organization", opt.Organization)
+	return params
+}
+
+// Users returns the users with their permissions.
+func (s *PermissionsService) Users(ctx context.Context, opt SearchPermissionsOptions) (*GetUser, error) {
+	users := GetUser{}
+	err := s.client.paginate(ctx, "api/permissions/users", opt.values(), &users)
+	if err != nil {
+		return nil, err
+	}
+	return &users, nil
+}
+
+// Groups returns the groups with their permissions.
+func (s *PermissionsService) Groups(ctx context.Context, opt SearchPermissionsOptions) (*GetGroupPermissions, error) {
+	groups := GetGroupPermissions{}
+	err := s.client.paginate(ctx, "api/permissions/groups", opt.values(), &groups)
+	if err != nil {
+		return nil, err
+	}
+	return &groups, nil
+}
+
+// TemplateUsers returns the users with their permissions on a permission
+// template.
+func (s *PermissionsService) TemplateUsers(ctx context.Context, opt SearchPermissionsOptions) (*GetUser, error) {
+	users := GetUser{}
+	err := s.client.paginate(ctx, "api/permissions/template_users", opt.values(), &users)
+	if err != nil {
+		return nil, err
+	}
+	return &users, nil
+}
+
+// TemplateGroups returns the groups with their permissions on a permission
+// template.
+func (s *PermissionsService) TemplateGroups(ctx context.Context, opt SearchPermissionsOptions) (*GetGroupPermissions, error) {
+	groups := GetGroupPermissions{}
+	err := s.client.paginate(ctx, "api/permissions/template_groups", opt.values(), &groups)
+	if err != nil {
+		return nil, err
+	}
+	return &groups, nil
+}
+
+// PermissionTemplateOptions are the parameters of
+// api/permissions/create_template and api/permissions/update_template.
+type PermissionTemplateOptions struct {
+	// ID is only used when updating a template
+	ID string
+	// Name is only used when creating a template
+	Name              string
+	Description       string
+	ProjectKeyPattern string
+	Organization      string
+}
+
+// CreateTemplate creates a permission template.
+func (s *PermissionsService) CreateTemplate(ctx context.Context, opt PermissionTemplateOptions) (*CreatePermissionTemplateResponse, error) {
+	params := url.Values{
+		"name":              []string{opt.Name},
+		"description":       []string{opt.Description},
+		"projectKeyPattern": []string{opt.ProjectKeyPattern},
+	}
+	setOptional(params, "organization", opt.Organization)
+
+	permissionTemplateResponse := CreatePermissionTemplateResponse{}
+	err := s.client.call(ctx, "POST", "api/permissions/create_template", params, http.StatusOK, &permissionTemplateResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &permissionTemplateResponse, nil
+}
+
+// SearchTemplates returns the permission templates matching q, requesting
+// every result page.
+func (s *PermissionsService) SearchTemplates(ctx context.Context, q string, organization string) (*GetPermissionTemplates, error) {
+	params := url.Values{
+		"q": []string{q},
+	}
+	setOptional(params, "organization", organization)
+
+	permissionTemplateReadResponse := GetPermissionTemplates{}
+	err := s.client.paginate(ctx, "api/permissions/search_templates", params, &permissionTemplateReadResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &permissionTemplateReadResponse, nil
+}
+
+// UpdateTemplate updates a permission template. Empty fields are cleared.
+func (s *PermissionsService) UpdateTemplate(ctx context.Context, opt PermissionTemplateOptions) error {
+	params := url.Values{
+		"id":                []string{opt.ID},
+		"description":       []string{opt.Description},
+		"projectKeyPattern": []string{opt.ProjectKeyPattern},
+	}
+
+	return s.client.call(ctx, "POST", "api/permissions/update_template", params, http.StatusOK, nil)
+}
+
+// DeleteTemplate deletes the permission template with the given id.
+func (s *PermissionsService) DeleteTemplate(ctx context.Context, id string) error {
+	params := url.Values{
+		"templateId": []string{id},
+	}
+
+	return s.client.call(ctx, "POST", "api/permissions/delete_template", params, http.StatusNoContent, nil)
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// PluginsService handles the api/plugins web service.
+type PluginsService struct {
+	client *Client
+}
+
+// Install installs the plugin with the given key.
+func (s *PluginsService) Install(ctx context.Context, key string) error {
+	params := url.Values{
+		"key": []string{key},
+	}
+
+	return s.client.call(ctx, "POST", "api/plugins/install", params, http.StatusNoContent, nil)
+}
+
+// Installed returns all installed plugins.
+func (s *PluginsService) Installed(ctx context.Context) (*GetInstalledPlugins, error) {
+	installedPlugins := GetInstalledPlugins{}
+	err := s.client.call(ctx, "GET", "api/plugins/installed", url.Values{}, http.StatusOK, &installedPlugins)
+	if err != nil {
+		return nil, err
+	}
+	return &installedPlugins, nil
+}
+
+// Pending returns the plugins that are installed, updated or removed when the
+// server restarts.
+func (s *PluginsService) Pending(ctx context.Context) (*GetPendingPlugins, error) {
+	pendingPlugins := GetPendingPlugins{}
+	err := s.client.call(ctx, "GET", "api/plugins/pending", url.Values{}, http.StatusOK, &pendingPlugins)
+	if err != nil {
+		return nil, err
+	}
+	return &pendingPlugins, nil
+}
+
+// Uninstall uninstalls the plugin with the given key.
+func (s *PluginsService) Uninstall(ctx context.Context, key string) error {
+	params := url.Values{
+		"key": []string{key},
+	}
+
+	return s.client.call(ctx, "POST", "api/plugins/uninstall", params, http.StatusNoContent, nil)
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// projectTagsPageSize is the largest page size api/project_tags/search
+// accepts
+const projectTagsPageSize = 100
+
+// ProjectTagsService handles the api/project_tags web service.
+type ProjectTagsService struct {
+	client *Client
+}
+
+// Set replaces the tags of a project. An empty list removes all tags.
+func (s *ProjectTagsService) Set(ctx context.Context, project string, tags []string) error {
+	params := url.Values{
+		"project": []string{project},
+		"tags":    []string{strings.Join(tags, ",")},
+	}
+
+	return s.client.call(ctx, "POST", "api/project_tags/set", params, http.StatusNoContent, nil)
+}
+
+// Search returns the tags used by the projects of the organization that match
+// q, requesting every page. Both are optional. Most servers return no paging
+// information, so pages are requested until one is not full. Servers that
+// ignore the page parameter answer with the first page again, then only that
+// page is returned. Truncated is set when the server reports a total above the
+// number of returned tags.
+func (s *ProjectTagsService) Search(ctx context.Context, organization string, q string) (*GetProjectTags, error) {
+	projectTags := GetProjectTags{Tags: []string{}}
+	seen := map[string]bool{}
+	for page := 1; ; page++ {
+		params := url.Values{
+			"p":  []string{strconv.Itoa(page)},
+			"ps": []string{strconv.Itoa(projectTagsPageSize)},
+		}
+		setOptional(params, "organization", organization)
+		setOptional(params, "q", q)
+
+		pageTags := GetProjectTags{}
+		err := s.client.call(ctx, "GET", "api/project_tags/search", params, http.StatusOK, &pageTags)
+		if err != nil {
+			return nil, err
+		}
+
+		projectTags.Paging = pageTags.Paging
+
+		for _, tag := range pageTags.Tags {
+			if seen[tag] {
+				return projectTags.checkTruncated(), nil
+			}
+			seen[tag] = true
+			projectTags.Tags = append(projectTags.Tags, tag)
+		}
+		if len(pageTags.Tags) < projectTagsPageSize {
+			return projectTags.checkTruncated(), nil
+		}
+	}
+}
+
+// checkTruncated sets Truncated when the server reported more tags than were
+// received.
+func (t *GetProjectTags) checkTruncated() *GetProjectTags {
+	t.Truncated = t.Paging != nil && t.Paging.Total > int64(len(t.Tags))
+	return t
+}
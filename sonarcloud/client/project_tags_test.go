@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+func TestProjectTagsSearch(t *testing.T) {
+	tests := []struct {
+		name string
+		// tags is the number of tags on the server
+		tags int
+		// ignorePages answers every request with the first page, total
+		// is reported in the paging when set
+		ignorePages bool
+		total       int64
+		// want is the number of returned tags
+		want      int
+		requests  int
+		truncated bool
+	}{
+		{name: "single page", tags: 30, want: 30, requests: 1},
+		{name: "full pages", tags: 200, want: 200, requests: 3},
+		{name: "last page", tags: 150, want: 150, requests: 2},
+		{name: "pages ignored without total", tags: 100, ignorePages: true, want: 100, requests: 2},
+		{name: "pages ignored with total", tags: 150, ignorePages: true, total: 150, want: 100, requests: 2, truncated: true},
+		{name: "pages ignored with all tags", tags: 100, ignorePages: true, total: 100, want: 100, requests: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				query := r.URL.Query()
+				if query.Get("organization") != "my-org" || query.Get("q") != "ja" {
+					t.Errorf("expected the parameters of the search on every page, got %s", r.URL.RawQuery)
+				}
+				page, err := strconv.Atoi(query.Get("p"))
+				if err != nil || test.ignorePages {
+					page = 1
+				}
+
+				response := GetProjectTags{Tags: []string{}}
+				if test.total > 0 {
+					response.Paging = &Paging{PageIndex: 1, PageSize: projectTagsPageSize, Total: test.total}
+				}
+				for i := (page - 1) * projectTagsPageSize; i < page*projectTagsPageSize && i < test.tags; i++ {
+					response.Tags = append(response.Tags, fmt.Sprintf("tag-%03d", i))
+				}
+				json.NewEncoder(w).Encode(response)
+			}))
+			defer server.Close()
+			serverURL, err := url.Parse(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			httpClient := retryablehttp.NewClient()
+			httpClient.Logger = nil
+			client := NewClient(httpClient, *serverURL)
+
+			projectTags, err := client.ProjectTags.Search(context.Background(), "my-org", "ja")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(projectTags.Tags) != test.want || projectTags.Truncated != test.truncated {
+				t.Errorf("expected %d tags and truncated %v, got %d and %v", test.want, test.truncated, len(projectTags.Tags), projectTags.Truncated)
+			}
+			if requests != test.requests {
+				t.Errorf("expected %d requests, got %d", test.requests, requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ProjectsService handles the api/projects web service.
+type ProjectsService struct {
+	client *Client
+}
+
+// CreateProjectOptions are the parameters of api/projects/create.
+type CreateProjectOptions struct {
+	Name         string
+	Project      string
+	Visibility   string
+	Organization string
+}
+
+// Create creates a project.
+func (s *ProjectsService) Create(ctx context.Context, opt CreateProjectOptions) (*CreateProjectResponse, error) {
+	params := url.Values{
+		"name":    []string{opt.Name},
+		"project": []string{opt.Project},
+	}
+	setOptional(params, "visibility", opt.Visibility)
+	setOptional(params, "organization", opt.Organization)
+
+	projectResponse := CreateProjectResponse{}
+	err := s.client.call(ctx, "POST", "api/projects/create", params, http.StatusOK, &projectResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &projectResponse, nil
+}
+
+// SearchProjectsOptions are the parameters of api/projects/search.
+type SearchProjectsOptions struct {
+	Projects     []string
+	Q            string
+	Organization string
+}
+
+// Search returns the projects matching opt, requesting every result page.
+func (s *ProjectsService) Search(ctx context.Context, opt SearchProjectsOptions) (*GetProject, error) {
+	params := url.Values{}
+	setOptional(params, "projects", strings.Join(opt.Projects, ","))
+	setOptional(params, "q", opt.Q)
+	setOptional(params, "organization", opt.Organization)
+
+	projectReadResponse := GetProject{}
+	err := s.client.paginate(ctx, "api/projects/search", params, &projectReadResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &projectReadResponse, nil
+}
+
+// Delete deletes the project with the given key.
+func (s *ProjectsService) Delete(ctx context.Context, project string) error {
+	params := url.Values{
+		"project": []string{project},
+	}
+
+	return s.client.call(ctx, "POST", "api/projects/delete", params, http.StatusNoContent, nil)
+}
+
+// UpdateKey changes the key of a project. The analysis history is kept.
+func (s *ProjectsService) UpdateKey(ctx context.Context, from string, to string) error {
+	params := url.Values{
+		"from": []string{from},
+		"to":   []string{to},
+	}
+
+	return s.client.call(ctx, "POST", "api/projects/update_key", params, http.StatusNoContent, nil)
+}
+
+// UpdateVisibility makes a project public or private.
+func (s *ProjectsService) UpdateVisibility(ctx context.Context, project string, visibility string) error {
+	params := url.Values{
+		"project":    []string{project},
+		"visibility": []string{visibility},
+	}
+
+	return s.client.call(ctx, "POST", "api/projects/update_visibility", params, http.StatusNoContent, nil)
+}
@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// QualityGatesService handles the api/qualitygates web service.
+type QualityGatesService struct {
+	client *Client
+}
+
+// Create creates a quality gate.
+func (s *QualityGatesService) Create(ctx context.Context, name string, organization string) (*CreateQualityGateResponse, error) {
+	params := url.Values{
+		"name": []string{name},
+	}
+	setOptional(params, "organization", organization)
+
+	qualityGateResponse := CreateQualityGateResponse{}
+	err := s.client.call(ctx, "POST", "api/qualitygates/create", params, http.StatusOK, &qualityGateResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &qualityGateResponse, nil
+}
+
+// Show returns the quality gate with the given id, including its conditions.
+func (s *QualityGatesService) Show(ctx context.Context, id string, organization string) (*GetQualityGate, error) {
+	params := url.Values{
+		"id": []string{id},
+	}
+	setOptional(params, "organization", organization)
+
+	qualityGateReadResponse := GetQualityGate{}
+	err := s.client.call(ctx, "GET", "api/qualitygates/show", params, http.StatusOK, &qualityGateReadResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &qualityGateReadResponse, nil
+}
+
+// Destroy deletes the quality gate with the given id.
+func (s *QualityGatesService) Destroy(ctx context.Context, id string, organization string) error {
+	params := url.Values{
+		"id": []string{id},
+	}
+	setOptional(params, "organization", organization)
+
+	return s.client.call(ctx, "POST", "api/qualitygates/destroy", params, http.StatusNoContent, nil)
+}
+
+// List returns the quality gates of an organization, including the built-in
+// ones.
+func (s *QualityGatesService) List(ctx context.Context, organization string) (*GetQualityGates, error) {
+	params := url.Values{}
+	setOptional(params, "organization", organization)
+
+	qualityGatesResponse := GetQualityGates{}
+	err := s.client.call(ctx, "GET", "api/qualitygates/list", params, http.StatusOK, &qualityGatesResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &qualityGatesResponse, nil
+}
+
+// Rename renames the quality gate with the given id.
+func (s *QualityGatesService) Rename(ctx context.Context, id string, name string, organization string) error {
+	params := url.Values{
+		"id":   []string{id},
+		"name": []string{name},
+	}
+	setOptional(params, "organization", organization)
+
+	return s.client.call(ctx, "POST", "api/qualitygates/rename", params, http.StatusOK, nil)
+}
+
+// Copy creates a quality gate with the conditions of the quality gate with the
+// given id.
+func (s *QualityGatesService) Copy(ctx context.Context, id string, name string, organization string) (*CreateQualityGateResponse, error) {
+	params := url.Values{
+		"id":   []string{id},
+		"name": []string{name},
+	}
+	setOptional(params, "organization", organization)
+
+	qualityGateResponse := CreateQualityGateResponse{}
+	err := s.client.call(ctx, "POST", "api/qualitygates/copy", params, http.StatusOK, &qualityGateResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &qualityGateResponse, nil
+}
+
+// SetAsDefault makes the quality gate with the given id the default one, used
+// by projects that are not associated with a quality gate.
+func (s *QualityGatesService) SetAsDefault(ctx context.Context, id string, organization string) error {
+	params := url.Values{
+		"id": []string{id},
+	}
+	setOptional(params, "organization", organization)
+
+	return s.client.call(ctx, "POST", "api/qualitygates/set_as_default", params, http.StatusNoContent, nil)
+}
+
+// QualityGateConditionOptions are the parameters of
+// api/qualitygates/create_condition and api/qualitygates/update_condition.
+type QualityGateConditionOptions struct {
+	// GateID is only used when creating a condition
+	GateID string
+	// ID is only used when updating a condition
+	ID           string
+	Error        string
+	Metric       string
+	OP           string
+	Organization string
+}
+
+func (opt QualityGateConditionOptions) values() url.Values {
+	params := url.Values{
+		"error":  []string{opt.Error},
+		"metric": []string{opt.Metric},
+		"op":     []string{opt.OP},
+	}
+	setOptional(params, "gateId", opt.GateID)
+	setOptional(params, "id", opt.ID)
+	setOptional(params, "organization", opt.Organization)
+	return params
+}
+
+// CreateCondition adds a condition to a quality gate.
+func (s *QualityGatesService) CreateCondition(ctx context.Context, opt QualityGateConditionOptions) (*CreateQualityGateConditionResponse, error) {
+	qualityGateConditionResponse := CreateQualityGateConditionResponse{}
+	err := s.client.call(ctx, "POST", "api/qualitygates/create_condition", opt.values(), http.StatusOK, &qualityGateConditionResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &qualityGateConditionResponse, nil
+}
+
+// UpdateCondition updates a condition of a quality gate.
+func (s *QualityGatesService) UpdateCondition(ctx context.Context, opt QualityGateConditionOptions) error {
+	return s.client.call(ctx, "POST", "api/qualitygates/update_condition", opt.values(), http.StatusOK, nil)
+}
+
+// DeleteCondition deletes the condition with the given id.
+func (s *QualityGatesService) DeleteCondition(ctx context.Context, id string, organization string) error {
+	params := url.Values{
+		"id": []string{id},
+	}
+	setOptional(params, "organization", organization)
+
+	return s.client.call(ctx, "POST", "api/qualitygates/delete_condition", params, http.StatusNoContent, nil)
+}
+
+// QualityGateRef identifies a quality gate by its id, or by its name on
+// servers that accept the gateName parameter.
+type QualityGateRef struct {
+	ID   string
+	Name string
+}
+
+func (r QualityGateRef) set(params url.Values) {
+	setOptional(params, "gateId", r.ID)
+	setOptional(params, "gateName", r.Name)
+}
+
+// Select associates a project with a quality gate.
+func (s *QualityGatesService) Select(ctx context.Context, gate QualityGateRef, projectKey string, organization string) error {
+	params := url.Values{
+		"projectKey": []string{projectKey},
+	}
+	gate.set(params)
+	setOptional(params, "organization", organization)
+
+	return s.client.call(ctx, "POST", "api/qualitygates/select", params, http.StatusNoContent, nil)
+}
+
+// Deselect removes the association of a project with a quality gate.
+func (s *QualityGatesService) Deselect(ctx context.Context, gate QualityGateRef, projectKey string, organization string) error {
+	params := url.Values{
+		"projectKey": []string{projectKey},
+	}
+	gate.set(params)
+	setOptional(params, "organization", organization)
+
+	return s.client.call(ctx, "POST", "api/qualitygates/deselect", params, http.StatusNoContent, nil)
+}
+
+// Search returns the projects associated with a quality gate, requesting every
+// result page.
+func (s *QualityGatesService) Search(ctx context.Context, gate QualityGateRef, organization string) (*GetQualityGateAssociation, error) {
+	params := url.Values{}
+	gate.set(params)
+	setOptional(params, "organization", organization)
+
+	qualityGateAssociationReadResponse := GetQualityGateAssociation{}
+	err := s.client.paginate(ctx, "api/qualitygates/search", params, &qualityGateAssociationReadResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &qualityGateAssociationReadResponse, nil
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// QualityProfilesService handles the api/qualityprofiles web service.
+type QualityProfilesService struct {
+	client *Client
+}
+
+// QualityProfileOptions identify a quality profile by its name, language and
+// organization.
+type QualityProfileOptions struct {
+	Name         string
+	Language     string
+	Organization string
+}
+
+// Create creates a quality profile.
+func (s *QualityProfilesService) Create(ctx context.Context, opt QualityProfileOptions) (*CreateQualityProfileResponse, error) {
+	params := url.Values{
+		"name":     []string{opt.Name},
+		"language": []string{opt.Language},
+	}
+	setOptional(params, "organization", opt.Organization)
+
+	qualityProfileResponse := CreateQualityProfileResponse{}
+	err := s.client.call(ctx, "POST", "api/qualityprofiles/create", params, http.StatusOK, &qualityProfileResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &qualityProfileResponse, nil
+}
+
+// Search returns the quality profiles matching opt. An empty name or
+// language matches every quality profile.
+func (s *QualityProfilesService) Search(ctx context.Context, opt QualityProfileOptions) (*GetQualityProfiles, error) {
+	params := url.Values{}
+	setOptional(params, "qualityProfile", opt.Name)
+	setOptional(params, "language", opt.Language)
+	setOptional(params, "organization", opt.Organization)
+
+	qualityProfileReadResponse := GetQualityProfiles{}
+	err := s.client.call(ctx, "GET", "api/qualityprofiles/search", params, http.StatusOK, &qualityProfileReadResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &qualityProfileReadResponse, nil
+}
+
+// Delete deletes the quality profile matching opt.
+func (s *QualityProfilesService) Delete(ctx context.Context, opt QualityProfileOptions) error {
+	params := url.Values{
+		"qualityProfile": []string{opt.Name},
+		"language":       []string{opt.Language},
+	}
+	setOptional(params, "organization", opt.Organization)
+
+	return s.client.call(ctx, "POST", "api/qualityprofiles/delete", params, http.StatusNoContent, nil)
+}
+
+// Copy copies the quality profile fromKey into a new profile named toName,
+// in the language and organization of the copied profile.
+func (s *QualityProfilesService) Copy(ctx context.Context, fromKey string, toName string) (*QualityProfile, error) {
+	params := url.Values{
+		"fromKey": []string{fromKey},
+		"toName":  []string{toName},
+	}
+
+	qualityProfileResponse := QualityProfile{}
+	err := s.client.call(ctx, "POST", "api/qualityprofiles/copy", params, http.StatusOK, &qualityProfileResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &qualityProfileResponse, nil
+}
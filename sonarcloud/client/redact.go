@@ -0,0 +1,125 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces secrets in everything that is recorded or logged
+const redacted = "REDACTED"
+
+// sensitiveParameters are form, query and JSON fields that are never recorded
+// or logged
+var sensitiveParameters = map[string]bool{
+	"password":         true,
+	"previousPassword": true,
+	"token":            true,
+}
+
+// scrubber removes credentials from requests and responses before they are
+// recorded or logged.
+type scrubber struct {
+	// secrets are replaced wherever they appear, e.g. the configured
+	// token or password
+	secrets []string
+}
+
+// readRequestBody returns the body of req and replaces it with an unread copy.
+func readRequestBody(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return string(body), nil
+}
+
+// url returns u without userinfo and with sensitive query parameters
+// redacted.
+func (s scrubber) url(u *url.URL) string {
+	scrubbed := *u
+	scrubbed.User = nil
+	scrubbed.RawQuery = s.form(u.RawQuery)
+	return scrubbed.String()
+}
+
+// form redacts sensitive parameters of a URL encoded form or query.
+func (s scrubber) form(form string) string {
+	if form == "" {
+		return ""
+	}
+
+	values, err := url.ParseQuery(form)
+	if err != nil {
+		return s.replaceSecrets(form)
+	}
+	for key := range values {
+		if sensitiveParameters[key] {
+			values.Set(key, redacted)
+		}
+	}
+	return s.replaceSecrets(values.Encode())
+}
+
+// json redacts sensitive fields of a JSON body, e.g. the token returned
+// by api/user_tokens/generate.
+func (s scrubber) json(body string) string {
+	// Not every response is JSON, e.g. api/server/version answers with
+	// plain text that would decode as a number
+	trimmed := strings.TrimSpace(body)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return s.replaceSecrets(body)
+	}
+
+	// Decode numbers as json.Number, so ids are written back unchanged
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return s.replaceSecrets(body)
+	}
+
+	scrubbed, err := json.Marshal(scrubValue(v))
+	if err != nil {
+		return s.replaceSecrets(body)
+	}
+	return s.replaceSecrets(string(scrubbed))
+}
+
+func scrubValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if sensitiveParameters[key] {
+				v[key] = redacted
+			} else {
+				v[key] = scrubValue(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = scrubValue(value)
+		}
+	}
+	return v
+}
+
+// replaceSecrets replaces every secret in text.
+func (s scrubber) replaceSecrets(text string) string {
+	for _, secret := range s.secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, redacted)
+			text = strings.ReplaceAll(text, url.QueryEscape(secret), redacted)
+		}
+	}
+	return text
+}
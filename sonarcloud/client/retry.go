@@ -0,0 +1,107 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+type contextKey int
+
+const nonIdempotentKey contextKey = iota
+
+// nonIdempotentEndpoints must not be repeated once the server received the
+// request. They create a new object on every call, or like
+// api/projects/update_key fail when repeated after they succeeded.
+var nonIdempotentEndpoints = map[string]bool{
+	"api/permissions/create_template":   true,
+	"api/projects/create":               true,
+	"api/projects/update_key":           true,
+	"api/qualitygates/copy":             true,
+	"api/qualitygates/create":           true,
+	"api/qualitygates/create_condition": true,
+	"api/qualityprofiles/copy":          true,
+	"api/qualityprofiles/create":        true,
+	"api/user_groups/create":            true,
+	"api/user_tokens/generate":          true,
+	"api/users/create":                  true,
+}
+
+// withIdempotency marks the request context when the request to path must not
+// be repeated once it reached the server.
+func withIdempotency(ctx context.Context, method string, path string) context.Context {
+	if method != "POST" || !nonIdempotentEndpoints[path] {
+		return ctx
+	}
+	return context.WithValue(ctx, nonIdempotentKey, true)
+}
+
+// CheckRetry is a retryablehttp.CheckRetry policy for the SonarCloud API. It
+// retries like retryablehttp.DefaultRetryPolicy, except for requests that
+// create an object. Those are only retried when the server certainly did not
+// process them: on a 429 response or when no connection could be made.
+func CheckRetry(ctx context.Context, resp *http.Response, err error) (bool, error) {
+	// do not retry on context.Canceled or context.DeadlineExceeded
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	if nonIdempotent, _ := ctx.Value(nonIdempotentKey).(bool); nonIdempotent {
+		if err != nil {
+			return isDialError(err), nil
+		}
+		return resp.StatusCode == http.StatusTooManyRequests, nil
+	}
+
+	return retryablehttp.DefaultRetryPolicy(ctx, resp, err)
+}
+
+// isDialError reports whether err happened while connecting to the server,
+// before any part of the request was sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// Backoff is a retryablehttp.Backoff that waits as long as the Retry-After
+// header of a 429 or 503 response asks for, but no longer than max. Without
+// the header it backs off exponentially between min and max.
+func Backoff(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	if resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if wait > max {
+				wait = max
+			}
+			return wait
+		}
+	}
+
+	return retryablehttp.DefaultBackoff(min, max, attemptNum, resp)
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{name: "seconds", value: "120", want: 120 * time.Second, ok: true},
+		{name: "zero", value: "0", want: 0, ok: true},
+		{name: "date", value: time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat), want: 90 * time.Second, ok: true},
+		{name: "past date", value: "Wed, 21 Oct 2015 07:28:00 GMT", want: 0, ok: true},
+		{name: "missing", value: ""},
+		{name: "negative", value: "-1"},
+		{name: "invalid", value: "soon"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wait, ok := parseRetryAfter(test.value)
+			if ok != test.ok {
+				t.Fatalf("expected ok to be %v, got %v", test.ok, ok)
+			}
+			// An HTTP date has a precision of one second
+			if wait > test.want || wait < test.want-time.Second {
+				t.Errorf("expected a wait of %s, got %s", test.want, wait)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	response := func(status int, retryAfter string) *http.Response {
+		resp := &http.Response{StatusCode: status, Header: http.Header{}}
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return resp
+	}
+
+	tests := []struct {
+		name       string
+		resp       *http.Response
+		attemptNum int
+		want       time.Duration
+	}{
+		{name: "rate limited", resp: response(http.StatusTooManyRequests, "5"), want: 5 * time.Second},
+		{name: "unavailable", resp: response(http.StatusServiceUnavailable, "7"), want: 7 * time.Second},
+		{name: "capped at max", resp: response(http.StatusTooManyRequests, "3600"), want: 30 * time.Second},
+		{name: "past date", resp: response(http.StatusTooManyRequests, "Wed, 21 Oct 2015 07:28:00 GMT"), want: 0},
+		{name: "without header", resp: response(http.StatusTooManyRequests, ""), attemptNum: 2, want: 4 * time.Second},
+		{name: "header ignored on other statuses", resp: response(http.StatusInternalServerError, "20"), attemptNum: 1, want: 2 * time.Second},
+		{name: "no response", attemptNum: 10, want: 30 * time.Second},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if wait := Backoff(time.Second, 30*time.Second, test.attemptNum, test.resp); wait != test.want {
+				t.Errorf("expected a wait of %s, got %s", test.want, wait)
+			}
+		})
+	}
+}
+
+func TestCheckRetry(t *testing.T) {
+	// A connection to a closed port fails while dialing
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener.Close()
+	_, dialErr := http.Get("http://" + listener.Addr().String())
+	if dialErr == nil {
+		t.Fatal("expected the connection to fail")
+	}
+
+	ctx := context.Background()
+	create := withIdempotency(ctx, "POST", "api/projects/create")
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		status int
+		err    error
+		want   bool
+	}{
+		{name: "read server error", ctx: withIdempotency(ctx, "GET", "api/projects/search"), status: http.StatusInternalServerError, want: true},
+		{name: "read rate limited", ctx: ctx, status: http.StatusTooManyRequests, want: true},
+		{name: "read success", ctx: ctx, status: http.StatusOK, want: false},
+		{name: "update server error", ctx: withIdempotency(ctx, "POST", "api/projects/update_visibility"), status: http.StatusBadGateway, want: true},
+		{name: "create reached the server", ctx: create, status: http.StatusInternalServerError, want: false},
+		{name: "create behind an unavailable proxy", ctx: create, status: http.StatusBadGateway, want: false},
+		{name: "create rate limited", ctx: create, status: http.StatusTooManyRequests, want: true},
+		{name: "create connect error", ctx: create, err: dialErr, want: true},
+		{name: "create connection reset", ctx: create, err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}, want: false},
+		{name: "cancelled", ctx: cancelled, status: http.StatusInternalServerError, want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var resp *http.Response
+			if test.err == nil {
+				resp = &http.Response{StatusCode: test.status, Header: http.Header{}}
+			}
+
+			retry, err := CheckRetry(test.ctx, resp, test.err)
+			if retry != test.want {
+				t.Errorf("expected retry to be %v, got %v", test.want, retry)
+			}
+			if test.ctx == cancelled && !errors.Is(err, context.Canceled) {
+				t.Errorf("expected the context error, got %v", err)
+			}
+		})
+	}
+}
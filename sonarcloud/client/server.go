@@ -0,0 +1,30 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// ServerService handles the api/server web service.
+type ServerService struct {
+	client *Client
+}
+
+// Version returns the version of the server as plain text.
+func (s *ServerService) Version(ctx context.Context) (string, error) {
+	resp, err := s.client.do(ctx, "GET", "api/server/version", url.Values{}, http.StatusOK)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read api/server/version response body: %+v", err)
+	}
+
+	return string(bodyBytes), nil
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// SettingsService handles the api/settings web service.
+type SettingsService struct {
+	client *Client
+}
+
+// SettingOptions select a setting and the component it applies to. Without a
+// component the setting applies to the organization, or globally on
+// SonarQube.
+type SettingOptions struct {
+	Key          string
+	Component    string
+	Branch       string
+	Organization string
+}
+
+func (opt SettingOptions) values() url.Values {
+	params := url.Values{}
+	setOptional(params, "component", opt.Component)
+	setOptional(params, "branch", opt.Branch)
+	setOptional(params, "organization", opt.Organization)
+	return params
+}
+
+// Set sets the value of a single valued setting.
+func (s *SettingsService) Set(ctx context.Context, opt SettingOptions, value string) error {
+	params := opt.values()
+	params.Set("key", opt.Key)
+	params.Set("value", value)
+
+	return s.client.call(ctx, "POST", "api/settings/set", params, http.StatusNoContent, nil)
+}
+
+// Values returns the value of the setting, which may be inherited from an
+// enclosing scope.
+func (s *SettingsService) Values(ctx context.Context, opt SettingOptions) (*GetSettings, error) {
+	params := opt.values()
+	params.Set("keys", opt.Key)
+
+	settings := GetSettings{}
+	err := s.client.call(ctx, "GET", "api/settings/values", params, http.StatusOK, &settings)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// Reset removes the value of the setting, so it inherits the value of the
+// enclosing scope again.
+func (s *SettingsService) Reset(ctx context.Context, opt SettingOptions) error {
+	params := opt.values()
+	params.Set("keys", opt.Key)
+
+	return s.client.call(ctx, "POST", "api/settings/reset", params, http.StatusNoContent, nil)
+}
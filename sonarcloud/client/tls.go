@@ -0,0 +1,83 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// TransportOptions configure how the client connects to the server.
+type TransportOptions struct {
+	// CACertFile is the path of a PEM encoded CA bundle used to verify the
+	// server certificate
+	CACertFile string
+	// CACertPEM is a PEM encoded CA bundle used to verify the server
+	// certificate
+	CACertPEM string
+	// ClientCert and ClientKey are the PEM encoded certificate and private
+	// key sent to servers that require client certificates
+	ClientCert string
+	ClientKey  string
+	// InsecureSkipVerify disables the verification of the server
+	// certificate
+	InsecureSkipVerify bool
+	// ProxyURL is the proxy all requests are sent through. The proxy
+	// environment variables are used when it is empty.
+	ProxyURL string
+}
+
+// ConfigureTransport applies options to transport. The CA bundles are added to
+// the system roots, so public certificates are still trusted.
+func ConfigureTransport(transport *http.Transport, options TransportOptions) error {
+	tlsConfig := transport.TLSClientConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	} else {
+		tlsConfig = tlsConfig.Clone()
+	}
+
+	if options.CACertFile != "" || options.CACertPEM != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+
+		if options.CACertFile != "" {
+			pem, err := ioutil.ReadFile(options.CACertFile)
+			if err != nil {
+				return fmt.Errorf("Unable to read CA certificate: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return fmt.Errorf("No PEM encoded certificate found in %s", options.CACertFile)
+			}
+		}
+		if options.CACertPEM != "" && !pool.AppendCertsFromPEM([]byte(options.CACertPEM)) {
+			return fmt.Errorf("No PEM encoded certificate found in the CA certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if options.ClientCert != "" || options.ClientKey != "" {
+		certificate, err := tls.X509KeyPair([]byte(options.ClientCert), []byte(options.ClientKey))
+		if err != nil {
+			return fmt.Errorf("Unable to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	tlsConfig.InsecureSkipVerify = options.InsecureSkipVerify
+	transport.TLSClientConfig = tlsConfig
+
+	if options.ProxyURL != "" {
+		proxyURL, err := url.Parse(options.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("Unable to parse proxy url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return nil
+}
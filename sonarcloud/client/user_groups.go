@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// UserGroupsService handles the api/user_groups web service.
+type UserGroupsService struct {
+	client *Client
+}
+
+// Create creates a group.
+func (s *UserGroupsService) Create(ctx context.Context, name string, description string, organization string) (*CreateGroupResponse, error) {
+	params := url.Values{
+		"name":        []string{name},
+		"description": []string{description},
+	}
+	setOptional(params, "organization", organization)
+
+	groupResponse := CreateGroupResponse{}
+	err := s.client.call(ctx, "POST", "api/user_groups/create", params, http.StatusOK, &groupResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &groupResponse, nil
+}
+
+// Search returns the groups whose name matches q, requesting every result
+// page.
+func (s *UserGroupsService) Search(ctx context.Context, q string, organization string) (*GetGroup, error) {
+	params := url.Values{
+		"q": []string{q},
+	}
+	setOptional(params, "organization", organization)
+
+	groupReadResponse := GetGroup{}
+	err := s.client.paginate(ctx, "api/user_groups/search", params, &groupReadResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &groupReadResponse, nil
+}
+
+// Update sets the description of the group with the given id. An empty
+// description clears it.
+func (s *UserGroupsService) Update(ctx context.Context, id string, description string) error {
+	params := url.Values{
+		"id":          []string{id},
+		"description": []string{description},
+	}
+
+	return s.client.call(ctx, "POST", "api/user_groups/update", params, http.StatusOK, nil)
+}
+
+// Delete deletes the group with the given id.
+func (s *UserGroupsService) Delete(ctx context.Context, id string) error {
+	params := url.Values{
+		"id": []string{id},
+	}
+
+	return s.client.call(ctx, "POST", "api/user_groups/delete", params, http.StatusNoContent, nil)
+}
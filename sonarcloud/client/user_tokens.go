@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// UserTokensService handles the api/user_tokens web service.
+type UserTokensService struct {
+	client *Client
+}
+
+// Generate generates a token for a user. The token value is only returned by
+// this call. The token never expires when expirationDate is empty, otherwise
+// it is a date in the format YYYY-MM-DD.
+func (s *UserTokensService) Generate(ctx context.Context, login string, name string, expirationDate string) (*Token, error) {
+	params := url.Values{
+		"login": []string{login},
+		"name":  []string{name},
+	}
+	setOptional(params, "expirationDate", expirationDate)
+
+	tokenResponse := Token{}
+	err := s.client.call(ctx, "POST", "api/user_tokens/generate", params, http.StatusOK, &tokenResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &tokenResponse, nil
+}
+
+// Search returns the tokens of a user.
+func (s *UserTokensService) Search(ctx context.Context, login string) (*GetTokens, error) {
+	params := url.Values{
+		"login": []string{login},
+	}
+
+	getTokensResponse := GetTokens{}
+	err := s.client.call(ctx, "GET", "api/user_tokens/search", params, http.StatusOK, &getTokensResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &getTokensResponse, nil
+}
+
+// Revoke revokes a token of a user.
+func (s *UserTokensService) Revoke(ctx context.Context, login string, name string) error {
+	params := url.Values{
+		"login": []string{login},
+		"name":  []string{name},
+	}
+
+	return s.client.call(ctx, "POST", "api/user_tokens/revoke", params, http.StatusNoContent, nil)
+}
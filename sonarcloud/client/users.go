@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// UsersService handles the api/users web service.
+type UsersService struct {
+	client *Client
+}
+
+// CreateUserOptions are the parameters of api/users/create.
+type CreateUserOptions struct {
+	Login    string
+	Name     string
+	Email    string
+	Password string
+	Local    bool
+}
+
+// Create creates a user.
+func (s *UsersService) Create(ctx context.Context, opt CreateUserOptions) (*CreateUserResponse, error) {
+	params := url.Values{
+		"login": []string{opt.Login},
+		"name":  []string{opt.Name},
+		"local": []string{strconv.FormatBool(opt.Local)},
+	}
+	setOptional(params, "password", opt.Password)
+	setOptional(params, "email", opt.Email)
+
+	userResponse := CreateUserResponse{}
+	err := s.client.call(ctx, "POST", "api/users/create", params, http.StatusOK, &userResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &userResponse, nil
+}
+
+// Search returns the users whose login, name or email matches q, requesting
+// every result page.
+func (s *UsersService) Search(ctx context.Context, q string) (*GetUser, error) {
+	params := url.Values{
+		"q": []string{q},
+	}
+
+	userResponse := GetUser{}
+	err := s.client.paginate(ctx, "api/users/search", params, &userResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &userResponse, nil
+}
+
+// Update sets the email of a user.
+func (s *UsersService) Update(ctx context.Context, login string, email string) error {
+	params := url.Values{
+		"login": []string{login},
+		"email": []string{email},
+	}
+
+	return s.client.call(ctx, "POST", "api/users/update", params, http.StatusOK, nil)
+}
+
+// ChangePassword sets the password of a user.
+func (s *UsersService) ChangePassword(ctx context.Context, login string, password string) error {
+	params := url.Values{
+		"login":    []string{login},
+		"password": []string{password},
+	}
+
+	return s.client.call(ctx, "POST", "api/users/change_password", params, http.StatusNoContent, nil)
+}
+
+// Deactivate deactivates a user.
+func (s *UsersService) Deactivate(ctx context.Context, login string) error {
+	params := url.Values{
+		"login": []string{login},
+	}
+
+	return s.client.call(ctx, "POST", "api/users/deactivate", params, http.StatusOK, nil)
+}
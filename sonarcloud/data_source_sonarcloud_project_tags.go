@@ -0,0 +1,66 @@
+package sonarcloud
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// Returns the data source represented by this file.
+func dataSourceSonarcloudProjectTags() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceSonarcloudProjectTagsRead,
+
+		// Define the fields of this schema.
+		Schema: map[string]*schema.Schema{
+			"organization": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"search": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"tags": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+func dataSourceSonarcloudProjectTagsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Data sources can not have a CustomizeDiff, check what
+	// requireOrganization checks for resources here
+	organization := getOrganization(d, m)
+	if organization == "" && m.(*ProviderConfiguration).platform == platformSonarCloud {
+		return attributeError("organization", errOrganizationRequired)
+	}
+	search := d.Get("search").(string)
+
+	projectTagsResponse, err := m.(*ProviderConfiguration).client.ProjectTags.Search(ctx, organization, search)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	tags := projectTagsResponse.Tags
+	sort.Strings(tags)
+
+	d.SetId(organization + "/" + search)
+	d.Set("tags", tags)
+
+	if projectTagsResponse.Truncated {
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  fmt.Sprintf("Only %d of %d project tags are listed", len(tags), projectTagsResponse.Paging.Total),
+			Detail:   "The server does not page the project tags. Use search to list fewer tags.",
+		}}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package sonarcloud
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/meetdpv/SonarCloud/tests/sonarcloudtest"
+)
+
+func TestAccSonarcloudProjectTagsDataSourceOrganization(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{Platform: sonarcloudtest.PlatformSonarCloud})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + `
+data "sonarcloud_project_tags" "all" {}
+`,
+				ExpectError: regexp.MustCompile(`organization must be set on the resource or the provider`),
+			},
+			{
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_project" "test" {
+  name         = "Billing"
+  project      = "billing"
+  organization = "my-org"
+  tags         = ["java"]
+}
+
+data "sonarcloud_project_tags" "all" {
+  organization = "my-org"
+  depends_on   = [sonarcloud_project.test]
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.sonarcloud_project_tags.all", "id", "my-org/"),
+					resource.TestCheckResourceAttr("data.sonarcloud_project_tags.all", "tags.#", "1"),
+					resource.TestCheckResourceAttr("data.sonarcloud_project_tags.all", "tags.0", "java"),
+				),
+			},
+		},
+	})
+}
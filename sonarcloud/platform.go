@@ -0,0 +1,116 @@
+package sonarcloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/meetdpv/SonarCloud/sonarcloud/client"
+)
+
+// The provider manages both SonarCloud and self-hosted SonarQube. They serve
+// the same web API, but SonarCloud scopes almost everything by organization
+// and does not allow managing users or plugins, while SonarQube has no
+// organizations at all.
+const (
+	platformSonarCloud = "sonarcloud"
+	platformSonarQube  = "sonarqube"
+)
+
+// SonarQube editions as returned by api/navigation/global
+const (
+	editionCommunity = "community"
+)
+
+// detectServer returns the platform and the SonarQube edition of the server
+// from api/navigation/global. A configured platform is kept. The edition is
+// empty on SonarCloud, which has no editions. When the server does not answer,
+// the platform is detected from host and a warning is returned, since only a
+// few resources depend on the edition.
+func detectServer(ctx context.Context, sonarcloudClient *client.Client, platform string, host string) (string, string, diag.Diagnostics) {
+	if platform == platformSonarCloud {
+		return platform, "", nil
+	}
+
+	globalNavigation, err := sonarcloudClient.Navigation.Global(ctx)
+	if err != nil {
+		summary := "Unable to detect the SonarQube edition"
+		if platform == "" {
+			platform = detectPlatform(nil, host)
+			summary = fmt.Sprintf("Unable to detect the platform and edition, assuming %s from the host", platform)
+		}
+		return platform, "", diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  summary,
+			Detail:   err.Error(),
+		}}
+	}
+
+	if platform == "" {
+		platform = detectPlatform(globalNavigation, host)
+	}
+	if platform != platformSonarQube {
+		return platform, "", nil
+	}
+	return platform, globalNavigation.Edition, nil
+}
+
+// detectPlatform returns the platform of a server from its global navigation.
+// SonarCloud sets sonar.sonarcloud.enabled, SonarQube returns its edition. The
+// host is only used when the navigation is missing or tells neither.
+func detectPlatform(globalNavigation *client.GlobalNavigation, host string) string {
+	if globalNavigation != nil {
+		if globalNavigation.Settings["sonar.sonarcloud.enabled"] == "true" {
+			return platformSonarCloud
+		}
+		if globalNavigation.Edition != "" {
+			return platformSonarQube
+		}
+	}
+
+	hostname := strings.ToLower(strings.Split(host, ":")[0])
+	if hostname == "sonarcloud.io" || strings.HasSuffix(hostname, ".sonarcloud.io") {
+		return platformSonarCloud
+	}
+	return platformSonarQube
+}
+
+// requireSonarQube fails the plan of resources that SonarCloud does not
+// support.
+func requireSonarQube(resourceType string) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+		if m == nil {
+			return nil
+		}
+
+		if m.(*ProviderConfiguration).platform != platformSonarQube {
+			return fmt.Errorf("%s is only supported on SonarQube, SonarCloud does not allow to manage it", resourceType)
+		}
+		return nil
+	}
+}
+
+// errOrganizationRequired is returned for objects scoped by organization when
+// neither the resource nor the provider sets one on SonarCloud.
+var errOrganizationRequired = errors.New("organization must be set on the resource or the provider, SonarCloud scopes it by organization")
+
+// requireOrganization fails the plan of resources scoped by organization when
+// neither the resource nor the provider sets one on SonarCloud.
+func requireOrganization(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if m == nil {
+		return nil
+	}
+
+	config := m.(*ProviderConfiguration)
+	if config.platform != platformSonarCloud || config.organization != "" {
+		return nil
+	}
+
+	if _, ok := d.GetOk("organization"); ok || !d.NewValueKnown("organization") {
+		return nil
+	}
+	return errOrganizationRequired
+}
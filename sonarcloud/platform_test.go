@@ -0,0 +1,63 @@
+package sonarcloud
+
+import (
+	"testing"
+
+	"github.com/meetdpv/SonarCloud/sonarcloud/client"
+)
+
+func TestDetectPlatform(t *testing.T) {
+	tests := []struct {
+		name             string
+		globalNavigation *client.GlobalNavigation
+		host             string
+		want             string
+	}{
+		{
+			name:             "sonarcloud setting",
+			globalNavigation: &client.GlobalNavigation{Settings: map[string]string{"sonar.sonarcloud.enabled": "true"}},
+			host:             "sonar.example.com",
+			want:             platformSonarCloud,
+		},
+		{
+			name:             "edition",
+			globalNavigation: &client.GlobalNavigation{Edition: "developer"},
+			host:             "sonarcloud.io",
+			want:             platformSonarQube,
+		},
+		{
+			name:             "sonarcloud setting disabled",
+			globalNavigation: &client.GlobalNavigation{Edition: "community", Settings: map[string]string{"sonar.sonarcloud.enabled": "false"}},
+			host:             "sonarcloud.io",
+			want:             platformSonarQube,
+		},
+		{
+			name:             "neither falls back to the host",
+			globalNavigation: &client.GlobalNavigation{Version: "8.0.0.29455"},
+			host:             "sonarcloud.io",
+			want:             platformSonarCloud,
+		},
+		{
+			name: "sonarcloud host",
+			host: "SonarCloud.io:443",
+			want: platformSonarCloud,
+		},
+		{
+			name: "sonarcloud subdomain",
+			host: "eu.sonarcloud.io",
+			want: platformSonarCloud,
+		},
+		{
+			name: "other host",
+			host: "sonarcloud.io.example.com",
+			want: platformSonarQube,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := detectPlatform(test.globalNavigation, test.host); got != test.want {
+				t.Errorf("expected %s, got %s", test.want, got)
+			}
+		})
+	}
+}
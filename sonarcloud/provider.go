@@ -1,50 +1,163 @@
 package sonarcloud
 
 import (
-	"encoding/binary"
+	"context"
 	"errors"
-	"io/ioutil"
+	"fmt"
 	"net/http"
 	"net/url"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/go-retryablehttp"
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
-	"github.com/hashicorp/terraform-plugin-sdk/terraform"
-	log "github.com/sirupsen/logrus"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/meetdpv/SonarCloud/sonarcloud/client"
 )
 
 var sonarcloudProvider *schema.Provider
 
+// defaultTimeout is the default timeout of every resource operation. It can be
+// changed with the timeouts block of a resource.
+const defaultTimeout = 5 * time.Minute
+
 // Provider for sonarcloud
-func Provider() terraform.ResourceProvider {
+func Provider() *schema.Provider {
 	sonarcloudProvider = &schema.Provider{
 		// Provider configuration
 		Schema: map[string]*schema.Schema{
 			"user": {
-				Type:        schema.TypeString,
-				DefaultFunc: schema.MultiEnvDefaultFunc([]string{"SONAR_USER", "SONARCLOUD_USER"}, nil),
-				Required:    true,
+				Type:          schema.TypeString,
+				DefaultFunc:   schema.MultiEnvDefaultFunc([]string{"SONAR_USER", "SONARCLOUD_USER"}, nil),
+				Optional:      true,
+				ConflictsWith: []string{"token", "credentials_command"},
 			},
 			"pass": {
-				Type:        schema.TypeString,
-				DefaultFunc: schema.MultiEnvDefaultFunc([]string{"SONAR_PASS", "SONARCLOUD_PASS"}, nil),
-				Required:    true,
+				Type:          schema.TypeString,
+				DefaultFunc:   schema.MultiEnvDefaultFunc([]string{"SONAR_PASS", "SONARCLOUD_PASS"}, nil),
+				Optional:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"token", "credentials_command"},
+			},
+			"token": {
+				Type:          schema.TypeString,
+				DefaultFunc:   schema.MultiEnvDefaultFunc([]string{"SONAR_TOKEN", "SONARCLOUD_TOKEN"}, nil),
+				Optional:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"user", "pass", "credentials_command"},
+			},
+			"credentials_command": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				MinItems:      1,
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				ConflictsWith: []string{"token", "user", "pass"},
+			},
+			"url": {
+				Type:          schema.TypeString,
+				DefaultFunc:   schema.MultiEnvDefaultFunc([]string{"SONAR_URL", "SONARCLOUD_URL"}, nil),
+				Optional:      true,
+				ConflictsWith: []string{"host", "scheme"},
+				ValidateFunc:  validation.IsURLWithHTTPorHTTPS,
 			},
 			"host": {
 				Type:        schema.TypeString,
 				DefaultFunc: schema.MultiEnvDefaultFunc([]string{"SONAR_HOST", "SONARCLOUD_HOST"}, nil),
-				Required:    true,
+				Optional:    true,
+			},
+			"organization": {
+				Type:        schema.TypeString,
+				DefaultFunc: schema.MultiEnvDefaultFunc([]string{"SONAR_ORGANIZATION", "SONARCLOUD_ORGANIZATION"}, nil),
+				Optional:    true,
+			},
+			"platform": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{platformSonarCloud, platformSonarQube}, false),
 			},
 			"scheme": {
 				Type:        schema.TypeString,
-				Default:     "https",
 				DefaultFunc: schema.MultiEnvDefaultFunc([]string{"SONAR_SCHEME", "SONARCLOUD_SCHEME"}, nil),
 				Optional:    true,
 			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      4,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"min_retry_wait": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"max_retry_wait": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      30,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"request_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"max_concurrent_requests": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"read_cache_ttl": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"ca_cert_file": {
+				Type:          schema.TypeString,
+				DefaultFunc:   schema.MultiEnvDefaultFunc([]string{"SONAR_CA_CERT_FILE", "SONARCLOUD_CA_CERT_FILE"}, nil),
+				Optional:      true,
+				ConflictsWith: []string{"ca_cert_pem"},
+			},
+			"ca_cert_pem": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"ca_cert_file"},
+			},
+			"client_cert": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				RequiredWith: []string{"client_key"},
+			},
+			"client_key": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				RequiredWith: []string{"client_cert"},
+			},
+			"insecure_skip_verify": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"proxy_url": {
+				Type:         schema.TypeString,
+				DefaultFunc:  schema.MultiEnvDefaultFunc([]string{"SONAR_PROXY_URL", "SONARCLOUD_PROXY_URL"}, nil),
+				Optional:     true,
+				ValidateFunc: validation.IsURLWithScheme([]string{"http", "https", "socks5"}),
+			},
 		},
 		// Add the resources supported by this provider to this map.
 		ResourcesMap: map[string]*schema.Resource{
 			"sonarcloud_group":                           resourceSonarcloudGroup(),
+			"sonarcloud_new_code_period":                 resourceSonarcloudNewCodePeriod(),
 			"sonarcloud_permission_template":             resourceSonarcloudPermissionTemplate(),
 			"sonarcloud_permissions":                     resourceSonarcloudPermissions(),
 			"sonarcloud_plugin":                          resourceSonarcloudPlugin(),
@@ -57,74 +170,278 @@ func Provider() terraform.ResourceProvider {
 			"sonarcloud_user":                            resourceSonarcloudUser(),
 			"sonarcloud_user_token":                      resourceSonarcloudUserToken(),
 		},
-		ConfigureFunc: configureProvider,
+		// Add the data sources supported by this provider to this map.
+		DataSourcesMap: map[string]*schema.Resource{
+			"sonarcloud_project_tags": dataSourceSonarcloudProjectTags(),
+		},
+		ConfigureContextFunc: configureProvider,
 	}
 	return sonarcloudProvider
 }
 
-//ProviderConfiguration contains the sonarcloud providers configuration
+// ProviderConfiguration contains the sonarcloud providers configuration
 type ProviderConfiguration struct {
-	httpClient    *retryablehttp.Client
-	sonarCloudURL url.URL
+	client        *client.Client
+	organization  string
+	serverVersion *version.Version
+	platform      string
+	edition       string
+	// credentials caches the token of the credentials command, if one is
+	// configured
+	credentials *client.CredentialsCommand
 }
 
-func configureProvider(d *schema.ResourceData) (interface{}, error) {
-	client := retryablehttp.NewClient()
+func configureProvider(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+	httpClient := retryablehttp.NewClient()
+	httpClient.RetryMax = d.Get("max_retries").(int)
+	httpClient.RetryWaitMin = time.Duration(d.Get("min_retry_wait").(int)) * time.Second
+	httpClient.RetryWaitMax = time.Duration(d.Get("max_retry_wait").(int)) * time.Second
+	httpClient.HTTPClient.Timeout = time.Duration(d.Get("request_timeout").(int)) * time.Second
+	httpClient.CheckRetry = client.CheckRetry
+	httpClient.Backoff = client.Backoff
+	// Hand the last response back once the retries are used up, so the
+	// error message of the API is reported instead of a generic one.
+	httpClient.ErrorHandler = retryablehttp.PassthroughErrorHandler
+	// Every attempt is logged by the client.LoggingTransport below, with
+	// the credentials redacted
+	httpClient.Logger = nil
 
-	sonarCloudURL := url.URL{
-		Scheme:     d.Get("scheme").(string),
-		Host:       d.Get("host").(string),
-		User:       url.UserPassword(d.Get("user").(string), d.Get("pass").(string)),
-		ForceQuery: true,
+	// retryablehttp always starts with a pooled *http.Transport
+	err := client.ConfigureTransport(httpClient.HTTPClient.Transport.(*http.Transport), client.TransportOptions{
+		CACertFile:         d.Get("ca_cert_file").(string),
+		CACertPEM:          d.Get("ca_cert_pem").(string),
+		ClientCert:         d.Get("client_cert").(string),
+		ClientKey:          d.Get("client_key").(string),
+		InsecureSkipVerify: d.Get("insecure_skip_verify").(bool),
+		ProxyURL:           d.Get("proxy_url").(string),
+	})
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+
+	sonarCloudURL, diags := serverURL(d)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	// Authenticate either with a user token, with a login and password or
+	// with a token from a credentials command. Tokens are added to every
+	// request by the client transport so they never end up in the URL.
+	token := d.Get("token").(string)
+	user := d.Get("user").(string)
+	var command []string
+	for _, arg := range d.Get("credentials_command").([]interface{}) {
+		command = append(command, arg.(string))
+	}
+	var credentials *client.CredentialsCommand
+	secrets := []string{token, d.Get("pass").(string)}
+
+	// Record the API traffic to a cassette, or replay it from one, when a
+	// cassette is configured. It sits below the authentication so the
+	// credentials can be scrubbed from what is recorded.
+	if cassettePath := os.Getenv("SONARCLOUD_CASSETTE"); cassettePath != "" {
+		mode := os.Getenv("SONARCLOUD_CASSETTE_MODE")
+		if mode == "" {
+			// Never reach the API by accident
+			mode = client.CassetteReplay
+		}
+
+		cassette, err := client.NewCassetteTransport(client.CassetteOptions{
+			Path:    cassettePath,
+			Mode:    mode,
+			Secrets: secrets,
+		}, httpClient.HTTPClient.Transport)
+		if err != nil {
+			return nil, diag.FromErr(err)
+		}
+		httpClient.HTTPClient.Transport = cassette
+	}
+
+	httpClient.HTTPClient.Transport = &client.LoggingTransport{
+		Base:    httpClient.HTTPClient.Transport,
+		Secrets: secrets,
+	}
+
+	// Limit the requests in flight across all resources, so a high
+	// -parallelism does not get the API to throttle us. Retries wait for
+	// a slot again, so a backoff never holds one.
+	if limit := d.Get("max_concurrent_requests").(int); limit > 0 {
+		httpClient.HTTPClient.Transport = client.NewConcurrencyLimitTransport(limit, httpClient.HTTPClient.Transport)
+	}
+
+	switch {
+	case len(command) > 0:
+		// The configuration can not set token or user as well, so they
+		// come from the environment
+		if token != "" || user != "" {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Warning,
+				Summary:       "The token and user of the environment are ignored",
+				Detail:        "credentials_command takes precedence over the SONAR_TOKEN, SONARCLOUD_TOKEN, SONAR_USER, SONARCLOUD_USER, SONAR_PASS and SONARCLOUD_PASS environment variables.",
+				AttributePath: cty.GetAttrPath("credentials_command"),
+			})
+		}
+		credentials = &client.CredentialsCommand{Command: command}
+		httpClient.HTTPClient.Transport = &client.CredentialsCommandTransport{
+			Credentials: credentials,
+			Base:        httpClient.HTTPClient.Transport,
+		}
+	case token != "" && user != "":
+		return nil, diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       "Only one of token or user/pass can be configured",
+			AttributePath: cty.GetAttrPath("token"),
+		}}
+	case token != "":
+		httpClient.HTTPClient.Transport = &client.TokenAuthTransport{
+			Token: token,
+			Base:  httpClient.HTTPClient.Transport,
+		}
+	case user != "":
+		sonarCloudURL.User = url.UserPassword(user, d.Get("pass").(string))
+	default:
+		return nil, diag.Errorf("Either token, user/pass or credentials_command must be configured")
+	}
+
+	sonarcloudClient := client.NewClient(httpClient, sonarCloudURL)
+	if ttl := d.Get("read_cache_ttl").(int); ttl > 0 {
+		sonarcloudClient.EnableReadCache(time.Duration(ttl) * time.Second)
 	}
 
 	// Check that the sonarcloud api is available and a supported version
-	err := sonarcloudHealth(client, sonarCloudURL)
+	serverVersion, err := sonarcloudHealth(ctx, sonarcloudClient)
 	if err != nil {
-		log.Error(err)
-		return nil, err
+		return nil, diag.FromErr(err)
+	}
+
+	// Detect whether we talk to SonarCloud or SonarQube, unless the
+	// platform is configured explicitly
+	platform, edition, serverDiags := detectServer(ctx, sonarcloudClient, d.Get("platform").(string), sonarCloudURL.Host)
+	diags = append(diags, serverDiags...)
+
+	organization := d.Get("organization").(string)
+	if organization != "" && platform == platformSonarQube {
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Warning,
+			Summary:       "The organization is ignored on SonarQube",
+			Detail:        "SonarQube has no organizations, resources are created without one.",
+			AttributePath: cty.GetAttrPath("organization"),
+		})
 	}
 
 	return &ProviderConfiguration{
-		httpClient:    client,
-		sonarCloudURL: sonarCloudURL,
+		client:        sonarcloudClient,
+		organization:  organization,
+		serverVersion: serverVersion,
+		platform:      platform,
+		edition:       edition,
+		credentials:   credentials,
+	}, diags
+}
+
+// serverURL returns the URL of the server from the url attribute, or from host
+// and scheme when it is not set. The path of url is kept, as the server may be
+// served under a context path.
+func serverURL(d *schema.ResourceData) (url.URL, diag.Diagnostics) {
+	if rawURL := d.Get("url").(string); rawURL != "" {
+		parsedURL, err := url.Parse(rawURL)
+		if err != nil {
+			return url.URL{}, attributeError("url", fmt.Errorf("Unable to parse url: %w", err))
+		}
+		if parsedURL.User != nil {
+			return url.URL{}, attributeError("url", errors.New("The url must not contain credentials, use token or user and pass instead"))
+		}
+		return url.URL{
+			Scheme:     parsedURL.Scheme,
+			Host:       parsedURL.Host,
+			Path:       parsedURL.Path,
+			ForceQuery: true,
+		}, nil
+	}
+
+	if d.Get("host").(string) == "" {
+		return url.URL{}, diag.Errorf("Either url or host must be configured")
+	}
+	// scheme has no schema default, as it would always conflict with url
+	scheme := d.Get("scheme").(string)
+	if scheme == "" {
+		scheme = "https"
+	}
+	return url.URL{
+		Scheme:     scheme,
+		Host:       d.Get("host").(string),
+		ForceQuery: true,
 	}, nil
 }
 
-func sonarcloudHealth(client *retryablehttp.Client, sonarcloud url.URL) error {
+// minimumVersion is the oldest server version the provider supports, the 7.9
+// LTS. Features added later are gated on capabilities.
+var minimumVersion = version.Must(version.NewVersion("7.9"))
+
+func sonarcloudHealth(ctx context.Context, sonarcloudClient *client.Client) (*version.Version, error) {
 	// Make request to sonarcloud version endpoint
-	sonarcloud.Path = "api/server/version"
-	req, err := retryablehttp.NewRequest("GET", sonarcloud.String(), http.NoBody)
+	serverVersion, err := sonarcloudClient.Server.Version(ctx)
 	if err != nil {
-		log.Error(err)
-		return errors.New("Unable to construct sonarcloud version request")
+		var transportErr *client.TransportError
+		if errors.As(err, &transportErr) {
+			return nil, fmt.Errorf("Unable to reach sonarcloud: %w", err)
+		}
+		return nil, fmt.Errorf("Sonarcloud version api did not return a 200: %w", err)
 	}
 
-	resp, err := client.Do(req)
+	// The version is returned as plain text, e.g. "8.9.1.44547"
+	parsedVersion, err := version.NewVersion(strings.TrimSpace(serverVersion))
 	if err != nil {
-		log.Error(err)
-		return errors.New("Unable to reach sonarcloud")
+		return nil, fmt.Errorf("Unable to parse sonarcloud version %q: %w", serverVersion, err)
 	}
-	defer resp.Body.Close()
 
-	// Check response code
-	if resp.StatusCode != http.StatusOK {
-		return errors.New("Sonarcloud version api did not return a 200")
+	if parsedVersion.LessThan(minimumVersion) {
+		return nil, fmt.Errorf("Unsupported version %s of sonarcloud. Minimum supported version is %s", parsedVersion, minimumVersion)
 	}
 
-	// Read in the response
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Error(err)
-		return errors.New("Failed to parse response body on GET sonarcloud version api")
-	}
+	return parsedVersion, nil
+}
 
-	// Convert response to a int.
-	version := binary.BigEndian.Uint64(bodyBytes)
-	if version < 8 {
-		log.Error(err)
-		return errors.New("Unsupported version of sonarcloud. Minimum supported version is 8")
+// getOrganization returns the organization of the resource. When the resource
+// does not set one the provider default is used. It is always empty on
+// SonarQube, as SonarQube has no organizations.
+func getOrganization(d *schema.ResourceData, m interface{}) string {
+	if m.(*ProviderConfiguration).platform == platformSonarQube {
+		return ""
 	}
+	if organization, ok := d.GetOk("organization"); ok {
+		return organization.(string)
+	}
+	return m.(*ProviderConfiguration).organization
+}
+
+// isNotFound reports whether err is an API error for an object that does not
+// exist, which means the object was deleted outside of Terraform.
+func isNotFound(err error) bool {
+	var apiErr *client.APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
 
-	return nil
+// attributeError returns err as an error diagnostic of the attribute.
+func attributeError(attribute string, err error) diag.Diagnostics {
+	return diag.Diagnostics{{
+		Severity:      diag.Error,
+		Summary:       err.Error(),
+		AttributePath: cty.GetAttrPath(attribute),
+	}}
+}
+
+// diagnosticsError returns the errors of diags as a single error, for callers
+// like importers that can not return diagnostics.
+func diagnosticsError(diags diag.Diagnostics) error {
+	var summaries []string
+	for _, d := range diags {
+		if d.Severity == diag.Error {
+			summaries = append(summaries, d.Summary)
+		}
+	}
+	if len(summaries) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(summaries, "; "))
 }
@@ -0,0 +1,630 @@
+package sonarcloud
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/meetdpv/SonarCloud/sonarcloud/client"
+	"github.com/meetdpv/SonarCloud/tests/sonarcloudtest"
+)
+
+// testAccProviderFactories start the provider in process for resource.Test
+var testAccProviderFactories = map[string]func() (*schema.Provider, error){
+	"sonarcloud": func() (*schema.Provider, error) {
+		return Provider(), nil
+	},
+}
+
+func TestProvider(t *testing.T) {
+	if err := Provider().InternalValidate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testAccServer starts a fake server for the duration of the test. The
+// options default to a SonarQube server that accepts any credentials.
+func testAccServer(t *testing.T, options sonarcloudtest.Options) *sonarcloudtest.Server {
+	t.Helper()
+	server := sonarcloudtest.NewServer(options)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// testAccCheckDestroy returns a CheckDestroy that reads every resource of
+// the given type back from the server with a fresh provider and fails when
+// one of them still exists.
+func testAccCheckDestroy(server *sonarcloudtest.Server, resourceType string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		provider := Provider()
+		diags := provider.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+			"url":      server.BaseURL(),
+			"token":    "sonarcloudtest",
+			"platform": server.Platform(),
+		}))
+		if diags.HasError() {
+			return fmt.Errorf("configuring the provider: %v", diags)
+		}
+
+		r := provider.ResourcesMap[resourceType]
+		for name, rs := range s.RootModule().Resources {
+			if rs.Type != resourceType {
+				continue
+			}
+
+			state, diags := r.RefreshWithoutUpgrade(context.Background(), rs.Primary, provider.Meta())
+			if diags.HasError() {
+				return fmt.Errorf("reading %s: %v", name, diags)
+			}
+			if state != nil && state.ID != "" {
+				return fmt.Errorf("%s %q still exists", name, state.ID)
+			}
+		}
+		return nil
+	}
+}
+
+func TestAccProviderCassette(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+	cassettePath := filepath.Join(t.TempDir(), "cassette.json")
+
+	// Log in with a password, so it ends up in the URL userinfo
+	providerConfig := fmt.Sprintf(`
+provider "sonarcloud" {
+  scheme   = %q
+  host     = %q
+  user     = "admin"
+  pass     = "provider-password"
+  platform = "sonarqube"
+}
+`, server.Scheme(), server.Host())
+
+	var token string
+	testCase := resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig + `
+resource "sonarcloud_user" "test" {
+  login_name = "jdoe"
+  name       = "John Doe"
+  email      = "jdoe@example.com"
+  password   = "user-password"
+}
+
+resource "sonarcloud_user_token" "test" {
+  login_name = sonarcloud_user.test.login_name
+  name       = "ci"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_user.test", "email", "jdoe@example.com"),
+					func(s *terraform.State) error {
+						token = s.RootModule().Resources["sonarcloud_user_token.test"].Primary.Attributes["token"]
+						return nil
+					},
+				),
+			},
+			{
+				Config: providerConfig + `
+resource "sonarcloud_user" "test" {
+  login_name = "jdoe"
+  name       = "John Doe"
+  email      = "john.doe@example.com"
+  password   = "user-password"
+}
+
+resource "sonarcloud_user_token" "test" {
+  login_name = sonarcloud_user.test.login_name
+  name       = "ci"
+}
+`,
+				Check: resource.TestCheckResourceAttr("sonarcloud_user.test", "email", "john.doe@example.com"),
+			},
+		},
+	}
+
+	t.Setenv("SONARCLOUD_CASSETTE", cassettePath)
+	t.Setenv("SONARCLOUD_CASSETTE_MODE", "record")
+	resource.Test(t, testCase)
+	if t.Failed() {
+		return
+	}
+
+	data, err := ioutil.ReadFile(cassettePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cassette := string(data)
+	if token == "" {
+		t.Fatal("no token was generated")
+	}
+	for _, secret := range []string{"provider-password", "user-password", token, "admin@", "Authorization", "Basic "} {
+		if strings.Contains(cassette, secret) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+	for _, request := range []string{"api/users/create", "api/users/update", "api/user_tokens/generate", "api/user_tokens/revoke", "api/users/deactivate"} {
+		if !strings.Contains(cassette, request) {
+			t.Errorf("cassette does not contain %s", request)
+		}
+	}
+
+	// Nothing may reach the server when replaying
+	server.Close()
+	t.Setenv("SONARCLOUD_CASSETTE_MODE", "replay")
+	resource.Test(t, testCase)
+}
+
+// testConfigureProvider configures a new provider for server with the token
+// and platform of server.ProviderConfig and the extra attributes.
+func testConfigureProvider(server *sonarcloudtest.Server, extra map[string]interface{}) diag.Diagnostics {
+	raw := map[string]interface{}{
+		"scheme":      server.Scheme(),
+		"host":        server.Host(),
+		"token":       "sonarcloudtest",
+		"platform":    server.Platform(),
+		"max_retries": 0,
+	}
+	for key, value := range extra {
+		raw[key] = value
+	}
+	return Provider().Configure(context.Background(), terraform.NewResourceConfigRaw(raw))
+}
+
+// testCertificate returns a PEM encoded certificate and key signed by parent,
+// or a self-signed CA certificate when parent is nil.
+func testCertificate(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "sonarcloudtest client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.Subject.CommonName = "sonarcloudtest CA"
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return certificate, key,
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestProviderTLS(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{TLS: true})
+
+	caCertFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := ioutil.WriteFile(caCertFile, []byte(server.CACertPEM()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		extra map[string]interface{}
+		err   string
+	}{
+		{name: "ca_cert_pem", extra: map[string]interface{}{"ca_cert_pem": server.CACertPEM()}},
+		{name: "ca_cert_file", extra: map[string]interface{}{"ca_cert_file": caCertFile}},
+		{name: "insecure_skip_verify", extra: map[string]interface{}{"insecure_skip_verify": true}},
+		{name: "untrusted", err: "certificate signed by unknown authority"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diags := testConfigureProvider(server, test.extra)
+			if test.err == "" {
+				if diags.HasError() {
+					t.Fatalf("configuring the provider: %v", diags)
+				}
+				return
+			}
+			if !diags.HasError() || !strings.Contains(fmt.Sprint(diags), test.err) {
+				t.Fatalf("expected an error containing %q, got %v", test.err, diags)
+			}
+		})
+	}
+}
+
+func TestProviderClientCertificate(t *testing.T) {
+	ca, caKey, _, _ := testCertificate(t, nil, nil)
+	_, _, clientCert, clientKey := testCertificate(t, ca, caKey)
+	_, _, otherCert, otherKey := testCertificate(t, nil, nil)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	server := testAccServer(t, sonarcloudtest.Options{ClientCAs: clientCAs})
+
+	tests := []struct {
+		name  string
+		extra map[string]interface{}
+		fails bool
+	}{
+		{name: "signed", extra: map[string]interface{}{"client_cert": clientCert, "client_key": clientKey}},
+		{name: "untrusted", extra: map[string]interface{}{"client_cert": otherCert, "client_key": otherKey}, fails: true},
+		{name: "missing", fails: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			extra := map[string]interface{}{"ca_cert_pem": server.CACertPEM()}
+			for key, value := range test.extra {
+				extra[key] = value
+			}
+
+			diags := testConfigureProvider(server, extra)
+			if diags.HasError() != test.fails {
+				t.Fatalf("configuring the provider: %v", diags)
+			}
+		})
+	}
+}
+
+func TestProviderProxyURL(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+
+	// A forward proxy for plain HTTP, which records the host and path of the
+	// proxied requests
+	var mu sync.Mutex
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		proxied = append(proxied, r.URL.Host+r.URL.Path)
+		mu.Unlock()
+
+		outReq := r.Clone(r.Context())
+		outReq.RequestURI = ""
+		resp, err := http.DefaultTransport.RoundTrip(outReq)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		for key, values := range resp.Header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	defer proxy.Close()
+
+	diags := testConfigureProvider(server, map[string]interface{}{"proxy_url": proxy.URL})
+	if diags.HasError() {
+		t.Fatalf("configuring the provider: %v", diags)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := server.Host() + "/api/server/version"
+	if len(proxied) == 0 || proxied[0] != want {
+		t.Fatalf("expected %s to be sent through the proxy, the proxy got %v", want, proxied)
+	}
+}
+
+func TestAccProviderContextPath(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{ContextPath: "/sonarqube"})
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A reverse proxy in front of the server, which records the paths of
+	// the requests
+	var mu sync.Mutex
+	var paths []string
+	reverseProxy := httputil.NewSingleHostReverseProxy(serverURL)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		reverseProxy.ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+
+	for _, contextPath := range []string{"/sonarqube", "/sonarqube/"} {
+		t.Run(contextPath, func(t *testing.T) {
+			mu.Lock()
+			paths = nil
+			mu.Unlock()
+
+			providerConfig := fmt.Sprintf(`
+provider "sonarcloud" {
+  url      = %q
+  token    = "sonarcloudtest"
+  platform = "sonarqube"
+}
+`, proxy.URL+contextPath)
+
+			resource.Test(t, resource.TestCase{
+				ProviderFactories: testAccProviderFactories,
+				CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_group"),
+				Steps: []resource.TestStep{
+					{
+						Config: providerConfig + `
+resource "sonarcloud_group" "test" {
+  name = "developers"
+}
+`,
+						Check: resource.TestCheckResourceAttr("sonarcloud_group.test", "name", "developers"),
+					},
+				},
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, want := range []string{"/sonarqube/api/server/version", "/sonarqube/api/user_groups/create", "/sonarqube/api/user_groups/delete"} {
+				found := false
+				for _, path := range paths {
+					found = found || path == want
+				}
+				if !found {
+					t.Errorf("expected a request to %s, got %v", want, paths)
+				}
+			}
+			for _, path := range paths {
+				if !strings.HasPrefix(path, "/sonarqube/api/") {
+					t.Errorf("expected every request under /sonarqube/api/, got %s", path)
+				}
+			}
+		})
+	}
+}
+
+func TestProviderDetectPlatform(t *testing.T) {
+	tests := []struct {
+		name    string
+		options sonarcloudtest.Options
+		// hideNavigation makes api/navigation/global fail
+		hideNavigation bool
+		platform       string
+		edition        string
+		warning        string
+	}{
+		{
+			name:     "sonarcloud",
+			options:  sonarcloudtest.Options{Platform: sonarcloudtest.PlatformSonarCloud},
+			platform: platformSonarCloud,
+		},
+		{
+			name:     "sonarqube",
+			options:  sonarcloudtest.Options{Edition: "enterprise"},
+			platform: platformSonarQube,
+			edition:  "enterprise",
+		},
+		{
+			name:           "no navigation",
+			options:        sonarcloudtest.Options{Platform: sonarcloudtest.PlatformSonarCloud},
+			hideNavigation: true,
+			platform:       platformSonarQube,
+			warning:        "Unable to detect the platform and edition, assuming sonarqube from the host",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := testAccServer(t, test.options)
+			serverURL, err := url.Parse(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reverseProxy := httputil.NewSingleHostReverseProxy(serverURL)
+			proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.hideNavigation && r.URL.Path == "/api/navigation/global" {
+					http.NotFound(w, r)
+					return
+				}
+				reverseProxy.ServeHTTP(w, r)
+			}))
+			defer proxy.Close()
+
+			// The server listens on 127.0.0.1, which the host would
+			// detect as SonarQube
+			provider := Provider()
+			diags := provider.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+				"url":         proxy.URL,
+				"token":       "sonarcloudtest",
+				"max_retries": 0,
+			}))
+			if diags.HasError() {
+				t.Fatalf("configuring the provider: %v", diags)
+			}
+
+			config := provider.Meta().(*ProviderConfiguration)
+			if config.platform != test.platform || config.edition != test.edition {
+				t.Errorf("expected the platform %q and edition %q, got %q and %q", test.platform, test.edition, config.platform, config.edition)
+			}
+			var want, warnings []string
+			if test.warning != "" {
+				want = []string{test.warning}
+			}
+			for _, d := range diags {
+				warnings = append(warnings, d.Summary)
+			}
+			if fmt.Sprint(warnings) != fmt.Sprint(want) {
+				t.Errorf("expected the warnings %q, got %q", want, warnings)
+			}
+		})
+	}
+}
+
+func TestProviderResourceTimeouts(t *testing.T) {
+	for name, r := range Provider().ResourcesMap {
+		if r.Timeouts == nil {
+			t.Errorf("%s declares no timeouts", name)
+			continue
+		}
+		operations := map[string]*time.Duration{
+			"create": r.Timeouts.Create,
+			"read":   r.Timeouts.Read,
+			"delete": r.Timeouts.Delete,
+		}
+		// Resources whose attributes all force a new resource can not
+		// be updated
+		if r.UpdateContext != nil {
+			operations["update"] = r.Timeouts.Update
+		}
+		for operation, timeout := range operations {
+			if timeout == nil || *timeout != defaultTimeout {
+				t.Errorf("expected the %s timeout of %s to default to %s, got %v", operation, name, defaultTimeout, timeout)
+			}
+		}
+	}
+}
+
+func TestAccProviderTimeout(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A reverse proxy in front of the server, where creating a group
+	// stalls until the test ends
+	stalled := make(chan struct{})
+	reverseProxy := httputil.NewSingleHostReverseProxy(serverURL)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/user_groups/create" {
+			select {
+			case <-stalled:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		reverseProxy.ServeHTTP(w, r)
+	}))
+	defer proxy.Close()
+	defer close(stalled)
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "sonarcloud" {
+  url      = %q
+  token    = "sonarcloudtest"
+  platform = "sonarqube"
+}
+
+resource "sonarcloud_group" "test" {
+  name = "developers"
+
+  timeouts {
+    create = "1s"
+  }
+}
+`, proxy.URL),
+				ExpectError: regexp.MustCompile(`POST api/user_groups/create timed out`),
+			},
+		},
+	})
+}
+
+func TestProviderCredentialsCommand(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{Token: "squ_1"})
+	dir := t.TempDir()
+	credentialsPath := filepath.Join(dir, "credentials.json")
+	runsPath := filepath.Join(dir, "runs")
+	setToken := func(token string) {
+		server.SetToken(token)
+		if err := ioutil.WriteFile(credentialsPath, []byte(fmt.Sprintf(`{"token": %q}`, token)), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runs := func() int {
+		data, err := ioutil.ReadFile(runsPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Count(string(data), "\n")
+	}
+
+	setToken("squ_1")
+	provider := Provider()
+	diags := provider.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"scheme":              server.Scheme(),
+		"host":                server.Host(),
+		"platform":            server.Platform(),
+		"credentials_command": []interface{}{"sh", "-c", fmt.Sprintf("echo run >> %q && cat %q", runsPath, credentialsPath)},
+	}))
+	if diags.HasError() {
+		t.Fatalf("configuring the provider: %v", diags)
+	}
+	if runs() != 1 {
+		t.Fatalf("expected the command to run once, it ran %d times", runs())
+	}
+
+	// The revoked token is replaced, and the rejected request retried
+	setToken("squ_2")
+	_, err := provider.Meta().(*ProviderConfiguration).client.Projects.Create(context.Background(), client.CreateProjectOptions{
+		Name:    "Billing",
+		Project: "billing",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs() != 2 {
+		t.Errorf("expected the command to run again, it ran %d times", runs())
+	}
+}
+
+func TestProviderCredentialsCommandEnvironment(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{Token: "squ_1"})
+	t.Setenv("SONAR_TOKEN", "squ_environment")
+
+	diags := Provider().Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"scheme":              server.Scheme(),
+		"host":                server.Host(),
+		"platform":            server.Platform(),
+		"credentials_command": []interface{}{"echo", `{"token": "squ_1"}`},
+	}))
+	if diags.HasError() {
+		t.Fatalf("configuring the provider: %v", diags)
+	}
+	if len(diags) != 1 || diags[0].Severity != diag.Warning || !strings.Contains(diags[0].Summary, "environment are ignored") {
+		t.Errorf("expected a warning that the token of the environment is ignored, got %v", diags)
+	}
+}
@@ -1,24 +1,29 @@
 package sonarcloud
 
 import (
-	"encoding/json"
-	"fmt"
-	"net/http"
-	"net/url"
+	"context"
 	"strconv"
 
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 // Returns the resource represented by this file.
 func resourceSonarcloudGroup() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceSonarcloudGroupCreate,
-		Read:   resourceSonarcloudGroupRead,
-		Update: resourceSonarcloudGroupUpdate,
-		Delete: resourceSonarcloudGroupDelete,
+		CreateContext: resourceSonarcloudGroupCreate,
+		ReadContext:   resourceSonarcloudGroupRead,
+		UpdateContext: resourceSonarcloudGroupUpdate,
+		DeleteContext: resourceSonarcloudGroupDelete,
 		Importer: &schema.ResourceImporter{
-			State: resourceSonarcloudGroupImport,
+			StateContext: resourceSonarcloudGroupImport,
+		},
+		CustomizeDiff: requireOrganization,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultTimeout),
+			Read:   schema.DefaultTimeout(defaultTimeout),
+			Update: schema.DefaultTimeout(defaultTimeout),
+			Delete: schema.DefaultTimeout(defaultTimeout),
 		},
 
 		// Define the fields of this schema.
@@ -32,72 +37,42 @@ func resourceSonarcloudGroup() *schema.Resource {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
-                        "organization": {
-                                Type:     schema.TypeString,
-                                Required: true,
-                        },
+			"organization": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				// Read stores the organization the group was created in,
+				// keep it when the configuration uses the provider default
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return new == ""
+				},
+			},
 		},
 	}
 }
 
-func resourceSonarcloudGroupCreate(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/user_groups/create"
-	sonarCloudURL.RawQuery = url.Values{
-		"name":        []string{d.Get("name").(string)},
-		"description": []string{d.Get("description").(string)},
-                "organization": []string{d.Get("organization").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceSonarcloudGroupCreate",
+func resourceSonarcloudGroupCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	groupResponse, err := m.(*ProviderConfiguration).client.UserGroups.Create(ctx,
+		d.Get("name").(string),
+		d.Get("description").(string),
+		getOrganization(d, m),
 	)
 	if err != nil {
-		return fmt.Errorf("Error creating Sonarcloud group: %+v", err)
-	}
-	defer resp.Body.Close()
-
-	// Decode response into struct
-	groupResponse := CreateGroupResponse{}
-	err = json.NewDecoder(resp.Body).Decode(&groupResponse)
-	if err != nil {
-		return fmt.Errorf("resourceSonarcloudGroupRead: Failed to decode json into struct: %+v", err)
+		return diag.Errorf("Error creating Sonarcloud group: %+v", err)
 	}
 
 	d.SetId(strconv.Itoa(groupResponse.Group.ID))
-	return resourceSonarcloudGroupRead(d, m)
+	return resourceSonarcloudGroupRead(ctx, d, m)
 }
 
-func resourceSonarcloudGroupRead(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/user_groups/search"
-	sonarCloudURL.RawQuery = url.Values{
-		"name": []string{d.Get("name").(string)},
-                "organization": []string{d.Get("organization").(string)},
-
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"GET",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceSonarcloudGroupRead",
+func resourceSonarcloudGroupRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	organization := getOrganization(d, m)
+	groupReadResponse, err := m.(*ProviderConfiguration).client.UserGroups.Search(ctx,
+		d.Get("name").(string),
+		organization,
 	)
 	if err != nil {
-		return fmt.Errorf("Error reading Sonarcloud group: %+v", err)
-	}
-	defer resp.Body.Close()
-
-	// Decode response into struct
-	groupReadResponse := GetGroup{}
-	err = json.NewDecoder(resp.Body).Decode(&groupReadResponse)
-	if err != nil {
-		return fmt.Errorf("resourceSonarcloudGroupRead: Failed to decode json into struct: %+v", err)
+		return diag.Errorf("Error reading Sonarcloud group: %+v", err)
 	}
 
 	// Loop over all groups to see if the group we need exists.
@@ -108,6 +83,11 @@ func resourceSonarcloudGroupRead(d *schema.ResourceData, m interface{}) error {
 			d.SetId(strconv.Itoa(value.ID))
 			d.Set("name", value.Name)
 			d.Set("description", value.Description)
+			// The search is scoped to the organization, SonarQube has
+			// no organizations
+			if organization != "" {
+				d.Set("organization", organization)
+			}
 			readSuccess = true
 		}
 	}
@@ -120,62 +100,27 @@ func resourceSonarcloudGroupRead(d *schema.ResourceData, m interface{}) error {
 	return nil
 }
 
-func resourceSonarcloudGroupUpdate(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/user_groups/update"
-
-	rawQuery := url.Values{
-		"id": []string{d.Id()},
-	}
-
-	if _, ok := d.GetOk("description"); ok {
-		rawQuery.Add("description", d.Get("description").(string))
-	} else {
-		rawQuery.Add("description", "")
-	}
-
-	sonarCloudURL.RawQuery = rawQuery.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceSonarcloudGroupUpdate",
-	)
+func resourceSonarcloudGroupUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	err := m.(*ProviderConfiguration).client.UserGroups.Update(ctx, d.Id(), d.Get("description").(string))
 	if err != nil {
-		return fmt.Errorf("Error updating Sonarcloud group: %+v", err)
+		return diag.Errorf("Error updating Sonarcloud group: %+v", err)
 	}
-	defer resp.Body.Close()
 
-	return resourceSonarcloudGroupRead(d, m)
+	return resourceSonarcloudGroupRead(ctx, d, m)
 }
 
-func resourceSonarcloudGroupDelete(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/user_groups/delete"
-	sonarCloudURL.RawQuery = url.Values{
-		"id": []string{d.Id()},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusNoContent,
-		"resourceSonarcloudGroupDelete",
-	)
+func resourceSonarcloudGroupDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	err := m.(*ProviderConfiguration).client.UserGroups.Delete(ctx, d.Id())
 	if err != nil {
-		return fmt.Errorf("Error deleting Sonarcloud group: %+v", err)
+		return diag.Errorf("Error deleting Sonarcloud group: %+v", err)
 	}
-	defer resp.Body.Close()
 
 	return nil
 }
 
-func resourceSonarcloudGroupImport(d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
-	if err := resourceSonarcloudGroupRead(d, m); err != nil {
-		return nil, err
+func resourceSonarcloudGroupImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	if diags := resourceSonarcloudGroupRead(ctx, d, m); diags.HasError() {
+		return nil, diagnosticsError(diags)
 	}
 	return []*schema.ResourceData{d}, nil
 }
@@ -0,0 +1,132 @@
+package sonarcloud
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/meetdpv/SonarCloud/tests/sonarcloudtest"
+)
+
+func TestAccSonarcloudGroup(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_group"),
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_group" "test" {
+  name        = "developers"
+  description = "All developers"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("sonarcloud_group.test", "id"),
+					resource.TestCheckResourceAttr("sonarcloud_group.test", "name", "developers"),
+					resource.TestCheckResourceAttr("sonarcloud_group.test", "description", "All developers"),
+				),
+			},
+			{
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_group" "test" {
+  name        = "developers"
+  description = "Every developer"
+}
+`,
+				Check: resource.TestCheckResourceAttr("sonarcloud_group.test", "description", "Every developer"),
+			},
+			{
+				ResourceName:      "sonarcloud_group.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccSonarcloudGroupOrganization(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{Platform: sonarcloudtest.PlatformSonarCloud})
+	var id string
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_group"),
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_group" "test" {
+  name = "developers"
+}
+`,
+				ExpectError: regexp.MustCompile(`organization must be set on the resource or the provider`),
+			},
+			{
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_group" "test" {
+  name         = "developers"
+  organization = "my-org"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_group.test", "organization", "my-org"),
+					func(s *terraform.State) error {
+						id = s.RootModule().Resources["sonarcloud_group.test"].Primary.ID
+						return nil
+					},
+				),
+			},
+			{
+				// Groups can not be moved, a new one is created
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_group" "test" {
+  name         = "developers"
+  organization = "other-org"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_group.test", "organization", "other-org"),
+					func(s *terraform.State) error {
+						if s.RootModule().Resources["sonarcloud_group.test"].Primary.ID == id {
+							return fmt.Errorf("expected the group to be replaced, it kept the ID %s", id)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccSonarcloudGroupProviderOrganization(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{Platform: sonarcloudtest.PlatformSonarCloud})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_group"),
+		Steps: []resource.TestStep{
+			{
+				// Read stores the organization of the provider without
+				// a change in the plan
+				Config: server.ProviderConfig(`organization = "my-org"`) + `
+resource "sonarcloud_group" "test" {
+  name = "developers"
+}
+`,
+				Check: resource.TestCheckResourceAttr("sonarcloud_group.test", "organization", "my-org"),
+			},
+			{
+				Config: server.ProviderConfig(`organization = "my-org"`) + `
+resource "sonarcloud_group" "test" {
+  name         = "developers"
+  organization = "my-org"
+}
+`,
+				PlanOnly: true,
+			},
+		},
+	})
+}
@@ -0,0 +1,249 @@
+package sonarcloud
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/meetdpv/SonarCloud/sonarcloud/client"
+)
+
+// New code definition types
+const (
+	newCodePeriodPreviousVersion = "PREVIOUS_VERSION"
+	newCodePeriodNumberOfDays    = "NUMBER_OF_DAYS"
+	newCodePeriodReferenceBranch = "REFERENCE_BRANCH"
+)
+
+// leakPeriodSetting is the setting that held the new code definition before
+// the api/new_code_periods web service existed
+const leakPeriodSetting = "sonar.leak.period"
+
+// Returns the resource represented by this file.
+func resourceSonarcloudNewCodePeriod() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceSonarcloudNewCodePeriodCreate,
+		ReadContext:   resourceSonarcloudNewCodePeriodRead,
+		UpdateContext: resourceSonarcloudNewCodePeriodUpdate,
+		DeleteContext: resourceSonarcloudNewCodePeriodDelete,
+		CustomizeDiff: resourceSonarcloudNewCodePeriodCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultTimeout),
+			Read:   schema.DefaultTimeout(defaultTimeout),
+			Update: schema.DefaultTimeout(defaultTimeout),
+			Delete: schema.DefaultTimeout(defaultTimeout),
+		},
+
+		// Define the fields of this schema.
+		Schema: map[string]*schema.Schema{
+			"organization": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"project": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"branch": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				RequiredWith: []string{"project"},
+			},
+			"type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice([]string{newCodePeriodPreviousVersion, newCodePeriodNumberOfDays, newCodePeriodReferenceBranch}, false),
+			},
+			"value": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+		},
+	}
+}
+
+func resourceSonarcloudNewCodePeriodCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	if diags := setNewCodePeriod(ctx, d, m); diags.HasError() {
+		return diags
+	}
+
+	d.SetId(newCodePeriodID(d, m))
+	return resourceSonarcloudNewCodePeriodRead(ctx, d, m)
+}
+
+func resourceSonarcloudNewCodePeriodRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfiguration)
+
+	if !config.supports(capabilityNewCodePeriods) {
+		settings, err := config.client.Settings.Values(ctx, leakPeriodSettingOptions(d, m))
+		if err != nil {
+			if isNotFound(err) {
+				d.SetId("")
+				return nil
+			}
+			return diag.FromErr(err)
+		}
+
+		for _, setting := range settings.Settings {
+			if setting.Key == leakPeriodSetting && !setting.Inherited {
+				periodType, value := fromLeakPeriod(setting.Value)
+				d.Set("type", periodType)
+				d.Set("value", value)
+				return nil
+			}
+		}
+
+		// The setting was reset outside of Terraform
+		d.SetId("")
+		return nil
+	}
+
+	newCodePeriod, err := config.client.NewCodePeriods.Show(ctx, newCodePeriodOptions(d, m))
+	if err != nil {
+		if isNotFound(err) {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+
+	// An inherited definition means the one of the project or branch was
+	// unset outside of Terraform
+	if newCodePeriod.Inherited {
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("type", newCodePeriod.Type)
+	d.Set("value", newCodePeriod.Value)
+	return nil
+}
+
+func resourceSonarcloudNewCodePeriodUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	if diags := setNewCodePeriod(ctx, d, m); diags.HasError() {
+		return diags
+	}
+	return resourceSonarcloudNewCodePeriodRead(ctx, d, m)
+}
+
+func resourceSonarcloudNewCodePeriodDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfiguration)
+
+	if !config.supports(capabilityNewCodePeriods) {
+		return diag.FromErr(config.client.Settings.Reset(ctx, leakPeriodSettingOptions(d, m)))
+	}
+	return diag.FromErr(config.client.NewCodePeriods.Unset(ctx, newCodePeriodOptions(d, m)))
+}
+
+// resourceSonarcloudNewCodePeriodCustomizeDiff checks that value matches the
+// type, and that the organization is known for the default of an organization.
+func resourceSonarcloudNewCodePeriodCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if _, ok := d.GetOk("project"); !ok && d.NewValueKnown("project") {
+		if err := requireOrganization(ctx, d, m); err != nil {
+			return err
+		}
+	}
+
+	if !d.NewValueKnown("type") || !d.NewValueKnown("value") {
+		return nil
+	}
+
+	value := d.Get("value").(string)
+	switch d.Get("type").(string) {
+	case newCodePeriodPreviousVersion:
+		if value != "" {
+			return fmt.Errorf("value must not be set for type %s", newCodePeriodPreviousVersion)
+		}
+	case newCodePeriodNumberOfDays:
+		if days, err := strconv.Atoi(value); err != nil || days < 1 || days > 90 {
+			return fmt.Errorf("value must be a number of days between 1 and 90 for type %s", newCodePeriodNumberOfDays)
+		}
+	case newCodePeriodReferenceBranch:
+		if value == "" {
+			return fmt.Errorf("value must be the name of the reference branch for type %s", newCodePeriodReferenceBranch)
+		}
+	}
+	return nil
+}
+
+// setNewCodePeriod sets the new code definition of the resource, through the
+// sonar.leak.period setting on servers without api/new_code_periods.
+func setNewCodePeriod(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfiguration)
+	opt := newCodePeriodOptions(d, m)
+
+	if !config.supports(capabilityNewCodePeriods) {
+		value, err := leakPeriodValue(config, opt.Type, opt.Value)
+		if err != nil {
+			return attributeError("type", err)
+		}
+		return diag.FromErr(config.client.Settings.Set(ctx, leakPeriodSettingOptions(d, m), value))
+	}
+	return diag.FromErr(config.client.NewCodePeriods.Set(ctx, opt))
+}
+
+func newCodePeriodOptions(d *schema.ResourceData, m interface{}) client.NewCodePeriodOptions {
+	return client.NewCodePeriodOptions{
+		Project:      d.Get("project").(string),
+		Branch:       d.Get("branch").(string),
+		Organization: getOrganization(d, m),
+		Type:         d.Get("type").(string),
+		Value:        d.Get("value").(string),
+	}
+}
+
+func leakPeriodSettingOptions(d *schema.ResourceData, m interface{}) client.SettingOptions {
+	return client.SettingOptions{
+		Key:          leakPeriodSetting,
+		Component:    d.Get("project").(string),
+		Branch:       d.Get("branch").(string),
+		Organization: getOrganization(d, m),
+	}
+}
+
+// newCodePeriodID returns the ID of the scope: <project>[/<branch>] for a
+// project or branch, the organization or "global" for the default.
+func newCodePeriodID(d *schema.ResourceData, m interface{}) string {
+	if project := d.Get("project").(string); project != "" {
+		if branch := d.Get("branch").(string); branch != "" {
+			return project + "/" + branch
+		}
+		return project
+	}
+	if organization := getOrganization(d, m); organization != "" {
+		return organization
+	}
+	return "global"
+}
+
+// leakPeriodValue returns the sonar.leak.period value of a new code
+// definition. The setting has no equivalent of a reference branch.
+func leakPeriodValue(config *ProviderConfiguration, periodType string, value string) (string, error) {
+	switch periodType {
+	case newCodePeriodPreviousVersion:
+		return "previous_version", nil
+	case newCodePeriodNumberOfDays:
+		return value, nil
+	default:
+		return "", config.requireCapability(capabilityNewCodePeriods)
+	}
+}
+
+// fromLeakPeriod returns the type and value of a sonar.leak.period value. A
+// date or version, which can not be managed by this resource, is returned
+// with an empty type so it shows up as a change.
+func fromLeakPeriod(leakPeriod string) (string, string) {
+	if leakPeriod == "previous_version" {
+		return newCodePeriodPreviousVersion, ""
+	}
+	if _, err := strconv.Atoi(leakPeriod); err == nil {
+		return newCodePeriodNumberOfDays, leakPeriod
+	}
+	return "", leakPeriod
+}
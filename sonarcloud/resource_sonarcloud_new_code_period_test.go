@@ -0,0 +1,127 @@
+package sonarcloud
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/meetdpv/SonarCloud/tests/sonarcloudtest"
+)
+
+func testAccSonarcloudNewCodePeriodConfig(server *sonarcloudtest.Server, newCodePeriod string) string {
+	return server.ProviderConfig() + `
+resource "sonarcloud_project" "test" {
+  name    = "Billing"
+  project = "billing"
+}
+` + newCodePeriod
+}
+
+func TestAccSonarcloudNewCodePeriod(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_new_code_period"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSonarcloudNewCodePeriodConfig(server, `
+resource "sonarcloud_new_code_period" "test" {
+  project = sonarcloud_project.test.project
+  type    = "PREVIOUS_VERSION"
+}
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_new_code_period.test", "id", "billing"),
+					resource.TestCheckResourceAttr("sonarcloud_new_code_period.test", "type", "PREVIOUS_VERSION"),
+					resource.TestCheckResourceAttr("sonarcloud_new_code_period.test", "value", ""),
+				),
+			},
+			{
+				Config: testAccSonarcloudNewCodePeriodConfig(server, `
+resource "sonarcloud_new_code_period" "test" {
+  project = sonarcloud_project.test.project
+  type    = "NUMBER_OF_DAYS"
+  value   = "30"
+}
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_new_code_period.test", "type", "NUMBER_OF_DAYS"),
+					resource.TestCheckResourceAttr("sonarcloud_new_code_period.test", "value", "30"),
+				),
+			},
+			{
+				Config: testAccSonarcloudNewCodePeriodConfig(server, `
+resource "sonarcloud_new_code_period" "test" {
+  project = sonarcloud_project.test.project
+  type    = "NUMBER_OF_DAYS"
+  value   = "365"
+}
+`),
+				ExpectError: regexp.MustCompile(`value must be a number of days between 1 and 90`),
+			},
+			{
+				ResourceName:  "sonarcloud_new_code_period.test",
+				ImportState:   true,
+				ImportStateId: "billing",
+				ExpectError:   regexp.MustCompile(`doesn't support import`),
+			},
+		},
+	})
+}
+
+func TestAccSonarcloudNewCodePeriodLeakPeriodSetting(t *testing.T) {
+	// 7.9 has no api/new_code_periods, the sonar.leak.period setting is
+	// used instead
+	server := testAccServer(t, sonarcloudtest.Options{Version: "7.9.6.48217"})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_new_code_period"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSonarcloudNewCodePeriodConfig(server, `
+resource "sonarcloud_new_code_period" "test" {
+  project = sonarcloud_project.test.project
+  type    = "PREVIOUS_VERSION"
+}
+`),
+				Check: resource.TestCheckResourceAttr("sonarcloud_new_code_period.test", "type", "PREVIOUS_VERSION"),
+			},
+			{
+				Config: testAccSonarcloudNewCodePeriodConfig(server, `
+resource "sonarcloud_new_code_period" "test" {
+  project = sonarcloud_project.test.project
+  type    = "NUMBER_OF_DAYS"
+  value   = "30"
+}
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_new_code_period.test", "type", "NUMBER_OF_DAYS"),
+					resource.TestCheckResourceAttr("sonarcloud_new_code_period.test", "value", "30"),
+				),
+			},
+			{
+				Config: testAccSonarcloudNewCodePeriodConfig(server, `
+resource "sonarcloud_new_code_period" "test" {
+  project = sonarcloud_project.test.project
+  type    = "REFERENCE_BRANCH"
+  value   = "main"
+}
+`),
+				ExpectError: regexp.MustCompile(`api/new_code_periods web service requires server version >= 8.0.0`),
+			},
+			{
+				// The failed update leaves the state alone
+				Config: testAccSonarcloudNewCodePeriodConfig(server, `
+resource "sonarcloud_new_code_period" "test" {
+  project = sonarcloud_project.test.project
+  type    = "NUMBER_OF_DAYS"
+  value   = "30"
+}
+`),
+				PlanOnly: true,
+			},
+		},
+	})
+}
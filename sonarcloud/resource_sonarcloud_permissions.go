@@ -1,22 +1,27 @@
 package sonarcloud
 
 import (
-	"encoding/json"
-	"fmt"
-	"net/http"
-	"net/url"
+	"context"
 	"strings"
 
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/meetdpv/SonarCloud/sonarcloud/client"
 	"github.com/satori/uuid"
 )
 
 // Returns the resource represented by this file.
 func resourceSonarcloudPermissions() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceSonarcloudPermissionsCreate,
-		Read:   resourceSonarcloudPermissionsRead,
-		Delete: resourceSonarcloudPermissionsDelete,
+		CreateContext: resourceSonarcloudPermissionsCreate,
+		ReadContext:   resourceSonarcloudPermissionsRead,
+		DeleteContext: resourceSonarcloudPermissionsDelete,
+		CustomizeDiff: requireOrganization,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultTimeout),
+			Read:   schema.DefaultTimeout(defaultTimeout),
+			Delete: schema.DefaultTimeout(defaultTimeout),
+		},
 
 		// Define the fields of this schema.
 		Schema: map[string]*schema.Schema{
@@ -53,126 +58,80 @@ func resourceSonarcloudPermissions() *schema.Resource {
 					Type: schema.TypeString,
 				},
 			},
+			"organization": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
 		},
 	}
 }
 
-func resourceSonarcloudPermissionsCreate(d *schema.ResourceData, m interface{}) error {
+func resourceSonarcloudPermissionsCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	permissionsService := m.(*ProviderConfiguration).client.Permissions
+	opt := permissionOptions(d, m)
 
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	permissions := expandPermissions(d)
-
-	// build the base query
-	RawQuery := url.Values{}
-
-	// if the permissions should be applied to a project
-	// we append the project_key to the request
-	if projectKey, ok := d.GetOk("project_key"); ok {
-		RawQuery.Add("projectKey", projectKey.(string))
-	}
-
-	// we use different API endpoints and request params
-	// based on the target principal type (group or user)
-	// and if its a direct or template permission
-	if _, ok := d.GetOk("login_name"); ok {
-		// user permission
-		RawQuery.Add("login", d.Get("login_name").(string))
-		if templateID, ok := d.GetOk("template_id"); ok {
-			// template user permission
-			sonarCloudURL.Path = "api/permissions/add_user_to_template"
-			RawQuery.Add("templateId", templateID.(string))
-		} else {
-			// direct user permission
-			sonarCloudURL.Path = "api/permissions/add_user"
-		}
-	} else {
-		// group permission
-		RawQuery.Add("groupName", d.Get("group_name").(string))
-		if templateID, ok := d.GetOk("template_id"); ok {
+	// we use different API endpoints based on the target
+	// principal type (group or user) and if its a direct
+	// or template permission
+	addPermission := permissionsService.AddGroup
+	if opt.Login != "" {
+		if opt.TemplateID != "" {
 			// template user permission
-			sonarCloudURL.Path = "api/permissions/add_group_to_template"
-			RawQuery.Add("templateId", templateID.(string))
+			addPermission = permissionsService.AddUserToTemplate
 		} else {
 			// direct user permission
-			sonarCloudURL.Path = "api/permissions/add_group"
+			addPermission = permissionsService.AddUser
 		}
+	} else if opt.TemplateID != "" {
+		// template group permission
+		addPermission = permissionsService.AddGroupToTemplate
 	}
 
 	// loop through all permissions that should be applied
-	for _, permission := range *&permissions {
-		CurrentRawQuery := RawQuery
-		CurrentRawQuery.Del("permission")
-		CurrentRawQuery.Add("permission", permission)
-		sonarCloudURL.RawQuery = CurrentRawQuery.Encode()
-
-		resp, err := httpRequestHelper(
-			m.(*ProviderConfiguration).httpClient,
-			"POST",
-			sonarCloudURL.String(),
-			http.StatusNoContent,
-			"resourceSonarcloudPermissionsCreate",
-		)
+	for _, permission := range expandPermissions(d) {
+		opt.Permission = permission
+		err := addPermission(ctx, opt)
 		if err != nil {
-			return fmt.Errorf("Error creating Sonarcloud permission: %+v", err)
+			return diag.Errorf("Error creating Sonarcloud permission: %+v", err)
 		}
-		defer resp.Body.Close()
 	}
 
 	// generate a unique ID
 	d.SetId(uuid.NewV4().String())
-	return resourceSonarcloudPermissionsRead(d, m)
+	return resourceSonarcloudPermissionsRead(ctx, d, m)
 }
 
-func resourceSonarcloudPermissionsRead(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-
-	// build the base query
-	RawQuery := url.Values{
-		// set the page size to 100
-		"ps": []string{"100"},
-	}
-
-	// if the permissions should be applied to a project
-	// we append the project_key to the request
-	if projectKey, ok := d.GetOk("project_key"); ok {
-		RawQuery.Add("projectKey", projectKey.(string))
+func resourceSonarcloudPermissionsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	permissionsService := m.(*ProviderConfiguration).client.Permissions
+	opt := client.SearchPermissionsOptions{
+		ProjectKey:   d.Get("project_key").(string),
+		TemplateID:   d.Get("template_id").(string),
+		Organization: getOrganization(d, m),
 	}
 
-	// we use different API endpoints and request params
-	// based on the target principal type (group or user)
-	// and if its a direct or template permission
+	// we use different API endpoints based on the target
+	// principal type (group or user) and if its a direct
+	// or template permission
 	if _, ok := d.GetOk("login_name"); ok {
 		// permission target is USER
-		if templateID, ok := d.GetOk("template_id"); ok {
+		searchUsers := permissionsService.Users
+		if opt.TemplateID != "" {
 			// template user permission
-			sonarCloudURL.Path = "api/permissions/template_users"
-			RawQuery.Add("templateId", templateID.(string))
-		} else {
-			// direct user permission
-			sonarCloudURL.Path = "api/permissions/users"
+			searchUsers = permissionsService.TemplateUsers
 		}
-		sonarCloudURL.RawQuery = RawQuery.Encode()
 
-		resp, err := httpRequestHelper(
-			m.(*ProviderConfiguration).httpClient,
-			"GET",
-			sonarCloudURL.String(),
-			http.StatusOK,
-			"resourceSonarcloudPermissionsRead",
-		)
-		if err != nil {
-			return fmt.Errorf("Error reading Sonarcloud permissions: %+v", err)
+		users, err := searchUsers(ctx, opt)
+		if isNotFound(err) {
+			// Project or permission template not found
+			d.SetId("")
+			return nil
 		}
-		defer resp.Body.Close()
-
-		// Decode response into struct
-		users := GetUser{}
-		err = json.NewDecoder(resp.Body).Decode(&users)
 		if err != nil {
-			return fmt.Errorf("resourceSonarcloudPermissionsRead: Failed to decode json into struct: %+v", err)
+			return diag.Errorf("Error reading Sonarcloud permissions: %+v", err)
 		}
 
-		// Loop over all groups to see if the group we need exists.
+		// Loop over all users to see if the user we need exists.
 		readSuccess := false
 		loginName := d.Get("login_name").(string)
 		for _, value := range users.Users {
@@ -190,33 +149,20 @@ func resourceSonarcloudPermissionsRead(d *schema.ResourceData, m interface{}) er
 
 	} else {
 		// permission target is GROUP
-		if templateID, ok := d.GetOk("template_id"); ok {
+		searchGroups := permissionsService.Groups
+		if opt.TemplateID != "" {
 			// template group permission
-			sonarCloudURL.Path = "api/permissions/template_groups"
-			RawQuery.Add("templateId", templateID.(string))
-		} else {
-			// direct group permission
-			sonarCloudURL.Path = "api/permissions/groups"
+			searchGroups = permissionsService.TemplateGroups
 		}
-		sonarCloudURL.RawQuery = RawQuery.Encode()
 
-		resp, err := httpRequestHelper(
-			m.(*ProviderConfiguration).httpClient,
-			"GET",
-			sonarCloudURL.String(),
-			http.StatusOK,
-			"resourceSonarcloudPermissionsRead",
-		)
-		if err != nil {
-			return fmt.Errorf("Error reading Sonarcloud permissions: %+v", err)
+		groups, err := searchGroups(ctx, opt)
+		if isNotFound(err) {
+			// Project or permission template not found
+			d.SetId("")
+			return nil
 		}
-		defer resp.Body.Close()
-
-		// Decode response into struct
-		groups := GetGroupPermissions{}
-		err = json.NewDecoder(resp.Body).Decode(&groups)
 		if err != nil {
-			return fmt.Errorf("resourceSonarcloudPermissionsRead: Failed to decode json into struct: %+v", err)
+			return diag.Errorf("Error reading Sonarcloud permissions: %+v", err)
 		}
 
 		// Loop over all groups to see if the group we need exists.
@@ -233,78 +179,57 @@ func resourceSonarcloudPermissionsRead(d *schema.ResourceData, m interface{}) er
 
 		if !readSuccess {
 			d.SetId("")
-			return fmt.Errorf("resourceSonarcloudPermissionsRead: Unable to find group permissions for group: %s", groupName)
+			return diag.Errorf("resourceSonarcloudPermissionsRead: Unable to find group permissions for group: %s", groupName)
 		}
 	}
 
 	return nil
 }
 
-func resourceSonarcloudPermissionsDelete(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	permissions := expandPermissions(d)
-
-	// build the base query
-	RawQuery := url.Values{}
+func resourceSonarcloudPermissionsDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	permissionsService := m.(*ProviderConfiguration).client.Permissions
+	opt := permissionOptions(d, m)
 
-	// if the permissions should be applied to a project
-	// we append the project_key to the request
-	if projectKey, ok := d.GetOk("project_key"); ok {
-		RawQuery.Add("projectKey", projectKey.(string))
-	}
-
-	// we use different API endpoints and request params
-	// based on the target principal type (group or user)
-	if _, ok := d.GetOk("login_name"); ok {
-		// permission target is USER
-		if templateID, ok := d.GetOk("template_id"); ok {
+	// we use different API endpoints based on the target
+	// principal type (group or user)
+	removePermission := permissionsService.RemoveGroup
+	if opt.Login != "" {
+		if opt.TemplateID != "" {
 			// template user permission
-			sonarCloudURL.Path = "api/permissions/remove_user_from_template"
-			RawQuery.Add("templateId", templateID.(string))
+			removePermission = permissionsService.RemoveUserFromTemplate
 		} else {
 			// direct user permission
-			sonarCloudURL.Path = "api/permissions/remove_user"
+			removePermission = permissionsService.RemoveUser
 		}
-		RawQuery.Add("login", d.Get("login_name").(string))
-		sonarCloudURL.RawQuery = RawQuery.Encode()
-
-	} else {
-		// permission target is GROUP
-		if templateID, ok := d.GetOk("template_id"); ok {
-			// template group permission
-			sonarCloudURL.Path = "api/permissions/remove_group_from_template"
-			RawQuery.Add("templateId", templateID.(string))
-		} else {
-			// direct group permission
-			sonarCloudURL.Path = "api/permissions/remove_group"
-		}
-		RawQuery.Add("groupName", d.Get("group_name").(string))
-		sonarCloudURL.RawQuery = RawQuery.Encode()
+	} else if opt.TemplateID != "" {
+		// template group permission
+		removePermission = permissionsService.RemoveGroupFromTemplate
 	}
 
-	// loop through all permissions that should be applied
-	for _, permission := range *&permissions {
-		CurrentRawQuery := RawQuery
-		CurrentRawQuery.Del("permission")
-		CurrentRawQuery.Add("permission", permission)
-		sonarCloudURL.RawQuery = CurrentRawQuery.Encode()
-
-		resp, err := httpRequestHelper(
-			m.(*ProviderConfiguration).httpClient,
-			"POST",
-			sonarCloudURL.String(),
-			http.StatusNoContent,
-			"resourceSonarcloudPermissionsDelete",
-		)
+	// loop through all permissions that should be removed
+	for _, permission := range expandPermissions(d) {
+		opt.Permission = permission
+		err := removePermission(ctx, opt)
 		if err != nil {
-			return fmt.Errorf("Error creating Sonarcloud permission: %+v", err)
+			return diag.Errorf("Error deleting Sonarcloud permission: %+v", err)
 		}
-		defer resp.Body.Close()
 	}
 
 	return nil
 }
 
+// permissionOptions returns the target of the permissions of the resource.
+// The permission itself is set per request.
+func permissionOptions(d *schema.ResourceData, m interface{}) client.PermissionOptions {
+	return client.PermissionOptions{
+		Login:        d.Get("login_name").(string),
+		GroupName:    d.Get("group_name").(string),
+		ProjectKey:   d.Get("project_key").(string),
+		TemplateID:   d.Get("template_id").(string),
+		Organization: getOrganization(d, m),
+	}
+}
+
 func expandPermissions(d *schema.ResourceData) []string {
 	expandedPermissions := make([]string, 0)
 	flatPermissions := d.Get("permissions").([]interface{})
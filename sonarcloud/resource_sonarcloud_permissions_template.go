@@ -1,24 +1,30 @@
 package sonarcloud
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
 	"log"
-	"net/http"
-	"net/url"
 
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/meetdpv/SonarCloud/sonarcloud/client"
 )
 
 // Returns the resource represented by this file.
 func resourceSonarcloudPermissionTemplate() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceSonarcloudPermissionTemplateCreate,
-		Read:   resourceSonarcloudPermissionTemplateRead,
-		Update: resourceSonarcloudPermissionTemplateUpdate,
-		Delete: resourceSonarcloudPermissionTemplateDelete,
+		CreateContext: resourceSonarcloudPermissionTemplateCreate,
+		ReadContext:   resourceSonarcloudPermissionTemplateRead,
+		UpdateContext: resourceSonarcloudPermissionTemplateUpdate,
+		DeleteContext: resourceSonarcloudPermissionTemplateDelete,
 		Importer: &schema.ResourceImporter{
-			State: resourceSonarcloudPermissionTemplateImport,
+			StateContext: resourceSonarcloudPermissionTemplateImport,
+		},
+		CustomizeDiff: requireOrganization,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultTimeout),
+			Read:   schema.DefaultTimeout(defaultTimeout),
+			Update: schema.DefaultTimeout(defaultTimeout),
+			Delete: schema.DefaultTimeout(defaultTimeout),
 		},
 
 		// Define the fields of this schema.
@@ -36,71 +42,42 @@ func resourceSonarcloudPermissionTemplate() *schema.Resource {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
+			"organization": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
 		},
 	}
 }
 
-func resourceSonarcloudPermissionTemplateCreate(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/permissions/create_template"
-	sonarCloudURL.RawQuery = url.Values{
-		"name":              []string{d.Get("name").(string)},
-		"description":       []string{d.Get("description").(string)},
-		"projectKeyPattern": []string{d.Get("project_key_pattern").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceSonarcloudPermissionTemplateCreate",
-	)
-	if err != nil {
-		return fmt.Errorf("Error creating Sonarcloud permission template: %+v", err)
-	}
-	defer resp.Body.Close()
-
-	// Decode response into struct
-	permissionTemplateResponse := CreatePermissionTemplateResponse{}
-	err = json.NewDecoder(resp.Body).Decode(&permissionTemplateResponse)
+func resourceSonarcloudPermissionTemplateCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	permissionTemplateResponse, err := m.(*ProviderConfiguration).client.Permissions.CreateTemplate(ctx, client.PermissionTemplateOptions{
+		Name:              d.Get("name").(string),
+		Description:       d.Get("description").(string),
+		ProjectKeyPattern: d.Get("project_key_pattern").(string),
+		Organization:      getOrganization(d, m),
+	})
 	if err != nil {
-		return fmt.Errorf("resourceSonarcloudPermissionTemplateCreate: Failed to decode json into struct: %+v", err)
+		return diag.Errorf("Error creating Sonarcloud permission template: %+v", err)
 	}
 
 	if permissionTemplateResponse.PermissionTemplate.ID != "" {
 		d.SetId(permissionTemplateResponse.PermissionTemplate.ID)
 	} else {
-		return fmt.Errorf("resourceSonarcloudPermissionTemplateCreate: Create response didn't contain an ID")
+		return diag.Errorf("resourceSonarcloudPermissionTemplateCreate: Create response didn't contain an ID")
 	}
 
-	return resourceSonarcloudPermissionTemplateRead(d, m)
+	return resourceSonarcloudPermissionTemplateRead(ctx, d, m)
 }
 
-func resourceSonarcloudPermissionTemplateRead(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/permissions/search_templates"
-	sonarCloudURL.RawQuery = url.Values{
-		"q": []string{d.Get("name").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"GET",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceSonarcloudPermissionTemplateRead",
+func resourceSonarcloudPermissionTemplateRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	permissionTemplateReadResponse, err := m.(*ProviderConfiguration).client.Permissions.SearchTemplates(ctx,
+		d.Get("name").(string),
+		getOrganization(d, m),
 	)
 	if err != nil {
-		return fmt.Errorf("Error reading Sonarcloud permission templates: %+v", err)
-	}
-	defer resp.Body.Close()
-
-	// Decode response into struct
-	permissionTemplateReadResponse := GetPermissionTemplates{}
-	err = json.NewDecoder(resp.Body).Decode(&permissionTemplateReadResponse)
-	if err != nil {
-		return fmt.Errorf("resourceSonarcloudPermissionTemplateRead: Failed to decode json into struct: %+v", err)
+		return diag.Errorf("Error reading Sonarcloud permission templates: %+v", err)
 	}
 
 	// Loop over all permission templates to see if the template we look for exists.
@@ -127,68 +104,31 @@ func resourceSonarcloudPermissionTemplateRead(d *schema.ResourceData, m interfac
 	return nil
 }
 
-func resourceSonarcloudPermissionTemplateUpdate(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/permissions/update_template"
-
-	rawQuery := url.Values{
-		"templateId": []string{d.Id()},
-	}
-
-	if _, ok := d.GetOk("description"); ok {
-		rawQuery.Add("description", d.Get("description").(string))
-	} else {
-		rawQuery.Add("description", "")
-	}
-
-	if _, ok := d.GetOk("project_key_pattern"); ok {
-		rawQuery.Add("projectKeyPattern", d.Get("project_key_pattern").(string))
-	} else {
-		rawQuery.Add("projectKeyPattern", "")
-	}
-
-	sonarCloudURL.RawQuery = rawQuery.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceSonarcloudPermissionTemplateUpdate",
-	)
+func resourceSonarcloudPermissionTemplateUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	err := m.(*ProviderConfiguration).client.Permissions.UpdateTemplate(ctx, client.PermissionTemplateOptions{
+		ID:                d.Id(),
+		Description:       d.Get("description").(string),
+		ProjectKeyPattern: d.Get("project_key_pattern").(string),
+	})
 	if err != nil {
-		return fmt.Errorf("Error updating Sonarcloud permission template: %+v", err)
+		return diag.Errorf("Error updating Sonarcloud permission template: %+v", err)
 	}
-	defer resp.Body.Close()
 
-	return resourceSonarcloudPermissionTemplateRead(d, m)
+	return resourceSonarcloudPermissionTemplateRead(ctx, d, m)
 }
 
-func resourceSonarcloudPermissionTemplateDelete(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/permissions/delete_template"
-	sonarCloudURL.RawQuery = url.Values{
-		"templateId": []string{d.Id()},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusNoContent,
-		"resourceSonarcloudPermissionTemplateDelete",
-	)
+func resourceSonarcloudPermissionTemplateDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	err := m.(*ProviderConfiguration).client.Permissions.DeleteTemplate(ctx, d.Id())
 	if err != nil {
-		return fmt.Errorf("Error deleting Sonarcloud permission template: %+v", err)
+		return diag.Errorf("Error deleting Sonarcloud permission template: %+v", err)
 	}
-	defer resp.Body.Close()
 
 	return nil
 }
 
-func resourceSonarcloudPermissionTemplateImport(d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
-	if err := resourceSonarcloudPermissionTemplateRead(d, m); err != nil {
-		return nil, err
+func resourceSonarcloudPermissionTemplateImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	if diags := resourceSonarcloudPermissionTemplateRead(ctx, d, m); diags.HasError() {
+		return nil, diagnosticsError(diags)
 	}
 	return []*schema.ResourceData{d}, nil
 }
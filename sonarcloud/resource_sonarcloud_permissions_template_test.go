@@ -0,0 +1,52 @@
+package sonarcloud
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/meetdpv/SonarCloud/tests/sonarcloudtest"
+)
+
+func TestAccSonarcloudPermissionTemplate(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_permission_template"),
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_permission_template" "test" {
+  name                = "internal"
+  description         = "Internal projects"
+  project_key_pattern = "internal_.*"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("sonarcloud_permission_template.test", "id"),
+					resource.TestCheckResourceAttr("sonarcloud_permission_template.test", "name", "internal"),
+					resource.TestCheckResourceAttr("sonarcloud_permission_template.test", "description", "Internal projects"),
+					resource.TestCheckResourceAttr("sonarcloud_permission_template.test", "project_key_pattern", "internal_.*"),
+				),
+			},
+			{
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_permission_template" "test" {
+  name                = "internal"
+  description         = "Projects of the internal tools"
+  project_key_pattern = "tools_.*"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_permission_template.test", "description", "Projects of the internal tools"),
+					resource.TestCheckResourceAttr("sonarcloud_permission_template.test", "project_key_pattern", "tools_.*"),
+				),
+			},
+			{
+				ResourceName:      "sonarcloud_permission_template.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
@@ -0,0 +1,98 @@
+package sonarcloud
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/meetdpv/SonarCloud/tests/sonarcloudtest"
+)
+
+func testAccSonarcloudPermissionsConfig(server *sonarcloudtest.Server, permissions string) string {
+	return server.ProviderConfig() + `
+resource "sonarcloud_user" "test" {
+  login_name = "jdoe"
+  name       = "John Doe"
+  password   = "secret"
+}
+
+resource "sonarcloud_group" "test" {
+  name = "developers"
+}
+
+resource "sonarcloud_project" "test" {
+  name    = "Billing"
+  project = "billing"
+}
+
+resource "sonarcloud_permission_template" "test" {
+  name = "internal"
+}
+` + permissions
+}
+
+func TestAccSonarcloudPermissions(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_permissions"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSonarcloudPermissionsConfig(server, `
+resource "sonarcloud_permissions" "global_user" {
+  login_name  = sonarcloud_user.test.login_name
+  permissions = ["gateadmin", "profileadmin"]
+}
+
+resource "sonarcloud_permissions" "project_group" {
+  group_name  = sonarcloud_group.test.name
+  project_key = sonarcloud_project.test.project
+  permissions = ["codeviewer", "user"]
+}
+
+resource "sonarcloud_permissions" "template_user" {
+  login_name  = sonarcloud_user.test.login_name
+  template_id = sonarcloud_permission_template.test.id
+  permissions = ["admin"]
+}
+
+resource "sonarcloud_permissions" "template_group" {
+  group_name  = sonarcloud_group.test.name
+  template_id = sonarcloud_permission_template.test.id
+  permissions = ["issueadmin"]
+}
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_permissions.global_user", "permissions.#", "2"),
+					resource.TestCheckResourceAttr("sonarcloud_permissions.global_user", "permissions.0", "gateadmin"),
+					resource.TestCheckResourceAttr("sonarcloud_permissions.global_user", "permissions.1", "profileadmin"),
+					resource.TestCheckResourceAttr("sonarcloud_permissions.project_group", "permissions.#", "2"),
+					resource.TestCheckResourceAttr("sonarcloud_permissions.template_user", "permissions.#", "1"),
+					resource.TestCheckResourceAttr("sonarcloud_permissions.template_user", "permissions.0", "admin"),
+					resource.TestCheckResourceAttr("sonarcloud_permissions.template_group", "permissions.#", "1"),
+					resource.TestCheckResourceAttr("sonarcloud_permissions.template_group", "permissions.0", "issueadmin"),
+				),
+			},
+			{
+				// Permissions can not be changed, a change replaces them
+				Config: testAccSonarcloudPermissionsConfig(server, `
+resource "sonarcloud_permissions" "global_user" {
+  login_name  = sonarcloud_user.test.login_name
+  permissions = ["gateadmin"]
+}
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_permissions.global_user", "permissions.#", "1"),
+					resource.TestCheckResourceAttr("sonarcloud_permissions.global_user", "permissions.0", "gateadmin"),
+				),
+			},
+			{
+				ResourceName:  "sonarcloud_permissions.global_user",
+				ImportState:   true,
+				ImportStateId: "jdoe",
+				ExpectError:   regexp.MustCompile(`doesn't support import`),
+			},
+		},
+	})
+}
@@ -1,22 +1,27 @@
 package sonarcloud
 
 import (
-	"encoding/json"
-	"net/http"
-	"net/url"
+	"context"
+	"fmt"
 
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
-	log "github.com/sirupsen/logrus"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 // Returns the resource represented by this file.
 func resourceSonarcloudPlugin() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceSonarcloudPluginCreate,
-		Read:   resourceSonarcloudPluginRead,
-		Delete: resourceSonarcloudPluginDelete,
+		CreateContext: resourceSonarcloudPluginCreate,
+		ReadContext:   resourceSonarcloudPluginRead,
+		DeleteContext: resourceSonarcloudPluginDelete,
 		Importer: &schema.ResourceImporter{
-			State: resourceSonarcloudPluginImport,
+			StateContext: resourceSonarcloudPluginImport,
+		},
+		CustomizeDiff: resourceSonarcloudPluginCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultTimeout),
+			Read:   schema.DefaultTimeout(defaultTimeout),
+			Delete: schema.DefaultTimeout(defaultTimeout),
 		},
 
 		// Define the fields of this schema.
@@ -30,91 +35,95 @@ func resourceSonarcloudPlugin() *schema.Resource {
 	}
 }
 
-func resourceSonarcloudPluginCreate(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/plugins/install"
-	sonarCloudURL.RawQuery = url.Values{
-		"key": []string{d.Get("key").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusNoContent,
-		"resourceSonarcloudPluginCreate",
-	)
+func resourceSonarcloudPluginCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	err := m.(*ProviderConfiguration).client.Plugins.Install(ctx, d.Get("key").(string))
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
-	defer resp.Body.Close()
 
 	d.SetId(d.Get("key").(string))
 	return nil
 }
 
-func resourceSonarcloudPluginRead(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/plugins/installed"
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"GET",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceSonarcloudPluginRead",
-	)
+func resourceSonarcloudPluginRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	getInstalledPlugins, err := m.(*ProviderConfiguration).client.Plugins.Installed(ctx)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
-	defer resp.Body.Close()
 
-	// Decode response into struct
-	getInstalledPlugins := GetInstalledPlugins{}
-	err = json.NewDecoder(resp.Body).Decode(&getInstalledPlugins)
+	// An installed plugin is only listed as installed once the server
+	// restarted, and stays listed until the restart after its removal
+	getPendingPlugins, err := m.(*ProviderConfiguration).client.Plugins.Pending(ctx)
 	if err != nil {
-		log.WithError(err).Error("resourceSonarcloudPluginRead: Failed to decode json into struct")
+		return diag.FromErr(err)
 	}
 
-	// Loop over all projects to see if the project we need exists.
-	for _, value := range getInstalledPlugins.Plugins {
+	// Loop over all plugins to see if the plugin we need exists.
+	readSuccess := false
+	for _, value := range append(getInstalledPlugins.Plugins, getPendingPlugins.Installing...) {
 		if d.Id() == value.Key {
-			// If it does, set the values of that project
+			// If it does, set the values of that plugin
 			d.SetId(value.Key)
 			d.Set("key", value.Key)
+			readSuccess = true
+		}
+	}
+	for _, value := range getPendingPlugins.Removing {
+		if d.Id() == value.Key {
+			readSuccess = false
 		}
 	}
 
+	if !readSuccess {
+		// Plugin not installed
+		d.SetId("")
+	}
+
 	return nil
 }
 
-func resourceSonarcloudPluginDelete(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/plugins/uninstall"
-	sonarCloudURL.RawQuery = url.Values{
-		"key": []string{d.Id()},
-	}.Encode()
-
-	log.Error(sonarCloudURL.String())
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusNoContent,
-		"resourceSonarcloudPluginDelete",
-	)
+func resourceSonarcloudPluginDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// The server can only uninstall a plugin after the restart that
+	// installed it
+	getPendingPlugins, err := m.(*ProviderConfiguration).client.Plugins.Pending(ctx)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
+	}
+	for _, value := range getPendingPlugins.Installing {
+		if d.Id() == value.Key {
+			return diag.Errorf("Plugin %q is installed when the server restarts, it can only be uninstalled after the restart", d.Id())
+		}
 	}
-	defer resp.Body.Close()
 
-	return nil
+	return diag.FromErr(m.(*ProviderConfiguration).client.Plugins.Uninstall(ctx, d.Id()))
 }
 
-func resourceSonarcloudPluginImport(d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
-	if err := resourceSonarcloudPluginRead(d, m); err != nil {
-		return nil, err
+func resourceSonarcloudPluginImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	key := d.Id()
+	if diags := resourceSonarcloudPluginRead(ctx, d, m); diags.HasError() {
+		return nil, diagnosticsError(diags)
+	}
+	if d.Id() == "" {
+		return nil, fmt.Errorf("Plugin %q is not installed", key)
 	}
 
 	return []*schema.ResourceData{d}, nil
 }
+
+// Plugins are installed from the marketplace, which only exists on the
+// SonarQube community edition.
+func resourceSonarcloudPluginCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if err := requireSonarQube("sonarcloud_plugin")(ctx, d, m); err != nil {
+		return err
+	}
+
+	if m == nil {
+		return nil
+	}
+
+	edition := m.(*ProviderConfiguration).edition
+	if edition != "" && edition != editionCommunity {
+		return fmt.Errorf("sonarcloud_plugin requires the SonarQube community edition, the marketplace is not available in the %s edition", edition)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package sonarcloud
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/meetdpv/SonarCloud/tests/sonarcloudtest"
+)
+
+func TestAccSonarcloudPlugin(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_plugin"),
+		Steps: []resource.TestStep{
+			{
+				// The plugin is pending installation until the restart
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_plugin" "test" {
+  key = "cobol"
+}
+`,
+				Check: resource.TestCheckResourceAttr("sonarcloud_plugin.test", "id", "cobol"),
+			},
+			{
+				PreConfig: server.Restart,
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_plugin" "test" {
+  key = "cobol"
+}
+`,
+				PlanOnly: true,
+			},
+			{
+				// A plugin can not be changed, another key replaces it
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_plugin" "test" {
+  key = "rpg"
+}
+`,
+				Check: resource.TestCheckResourceAttr("sonarcloud_plugin.test", "id", "rpg"),
+			},
+			{
+				ResourceName:      "sonarcloud_plugin.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				// The removed plugin is still installed until the restart
+				ResourceName:  "sonarcloud_plugin.test",
+				ImportState:   true,
+				ImportStateId: "cobol",
+				ExpectError:   regexp.MustCompile(`Plugin "cobol" is not installed`),
+			},
+			{
+				// A pending installation can not be removed
+				Config:      server.ProviderConfig(),
+				ExpectError: regexp.MustCompile(`Plugin "rpg" is installed when the server restarts`),
+			},
+			{
+				PreConfig: server.Restart,
+				Config:    server.ProviderConfig(),
+			},
+		},
+	})
+}
+
+func TestAccSonarcloudPluginCommercialEdition(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{Edition: "enterprise"})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_plugin" "test" {
+  key = "cobol"
+}
+`,
+				ExpectError: regexp.MustCompile(`requires the SonarQube community edition`),
+			},
+		},
+	})
+}
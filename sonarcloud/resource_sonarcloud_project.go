@@ -1,109 +1,122 @@
 package sonarcloud
 
 import (
-	"encoding/json"
-	"net/http"
-	"net/url"
-
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
-	log "github.com/sirupsen/logrus"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/meetdpv/SonarCloud/sonarcloud/client"
 )
 
 // Returns the resource represented by this file.
 func resourceSonarcloudProject() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceSonarcloudProjectCreate,
-		Read:   resourceSonarcloudProjectRead,
-		Delete: resourceSonarcloudProjectDelete,
+		CreateContext: resourceSonarcloudProjectCreate,
+		ReadContext:   resourceSonarcloudProjectRead,
+		UpdateContext: resourceSonarcloudProjectUpdate,
+		DeleteContext: resourceSonarcloudProjectDelete,
 		Importer: &schema.ResourceImporter{
-			State: resourceSonarcloudProjectImport,
+			StateContext: resourceSonarcloudProjectImport,
+		},
+		CustomizeDiff: resourceSonarcloudProjectCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultTimeout),
+			Read:   schema.DefaultTimeout(defaultTimeout),
+			Update: schema.DefaultTimeout(defaultTimeout),
+			Delete: schema.DefaultTimeout(defaultTimeout),
 		},
 
 		// Define the fields of this schema.
 		Schema: map[string]*schema.Schema{
+			// Neither SonarCloud nor SonarQube can rename a project
+			// through the web API, the name is set by the analysis.
+			// It is only sent on creation, and changing it fails the
+			// plan rather than replacing the project, which would lose
+			// its analysis history.
 			"name": {
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
 			},
 			"project": {
 				Type:     schema.TypeString,
 				Required: true,
-				ForceNew: true,
 			},
 			"visibility": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "public",
+				ValidateFunc: validation.StringInSlice([]string{"public", "private"}, false),
+			},
+			"organization": {
 				Type:     schema.TypeString,
 				Optional: true,
-				Default:  "public",
 				ForceNew: true,
+				// An imported project knows its organization, keep it
+				// when the configuration uses the provider default
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return new == ""
+				},
+			},
+			"tags": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+					// The server lower cases tags, other characters
+					// are rejected
+					ValidateFunc: validation.StringMatch(regexp.MustCompile(`^[a-z0-9+#.-]+$`), "tags may only contain lower case letters, digits and the characters + # - ."),
+				},
+			},
+			"qualifier": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"last_analysis_date": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"revision": {
+				Type:     schema.TypeString,
+				Computed: true,
 			},
-                        "organization": {
-                                Type:     schema.TypeString,
-                                Required: true,
-                                ForceNew: true,
-                        },
 		},
 	}
 }
 
-func resourceSonarcloudProjectCreate(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/projects/create"
-	sonarCloudURL.RawQuery = url.Values{
-		"name":       []string{d.Get("name").(string)},
-		"project":    []string{d.Get("project").(string)},
-		"visibility": []string{d.Get("visibility").(string)},
-                "organization": []string{d.Get("organization").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceSonarcloudProjectCreate",
-	)
+func resourceSonarcloudProjectCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	projectResponse, err := m.(*ProviderConfiguration).client.Projects.Create(ctx, client.CreateProjectOptions{
+		Name:         d.Get("name").(string),
+		Project:      d.Get("project").(string),
+		Visibility:   d.Get("visibility").(string),
+		Organization: getOrganization(d, m),
+	})
 	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	// Decode response into struct
-	projectResponse := CreateProjectResponse{}
-	err = json.NewDecoder(resp.Body).Decode(&projectResponse)
-	if err != nil {
-		log.WithError(err).Error("resourceSonarcloudProjectCreate: Failed to decode json into struct")
+		return diag.FromErr(err)
 	}
 
 	d.SetId(projectResponse.Project.Key)
-	return nil
-}
 
-func resourceSonarcloudProjectRead(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/projects/search"
-	sonarCloudURL.RawQuery = url.Values{
-		"project": []string{d.Id()},
-                "organization": []string{d.Get("organization").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"GET",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceSonarcloudProjectRead",
-	)
-	if err != nil {
-		return err
+	if tags := expandTags(d); len(tags) > 0 {
+		if err := m.(*ProviderConfiguration).client.ProjectTags.Set(ctx, d.Id(), tags); err != nil {
+			return diag.FromErr(err)
+		}
 	}
-	defer resp.Body.Close()
 
-	// Decode response into struct
-	projectReadResponse := GetProject{}
-	err = json.NewDecoder(resp.Body).Decode(&projectReadResponse)
+	return resourceSonarcloudProjectRead(ctx, d, m)
+}
+
+func resourceSonarcloudProjectRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	projectReadResponse, err := m.(*ProviderConfiguration).client.Projects.Search(ctx, client.SearchProjectsOptions{
+		Projects:     []string{d.Id()},
+		Organization: getOrganization(d, m),
+	})
 	if err != nil {
-		log.WithError(err).Error("resourceSonarcloudProjectRead: Failed to decode json into struct")
+		return diag.FromErr(err)
 	}
 
 	// Loop over all projects to see if the project we need exists.
@@ -112,9 +125,21 @@ func resourceSonarcloudProjectRead(d *schema.ResourceData, m interface{}) error
 		if d.Id() == value.Key {
 			// If it does, set the values of that project
 			d.SetId(value.Key)
-			d.Set("name", value.Name)
-			d.Set("key", value.Key)
+			// The analysis renames the project through sonar.projectName,
+			// keep the name it was created with so that does not show up
+			// as a change. An imported project has no name yet.
+			if d.Get("name").(string) == "" {
+				d.Set("name", value.Name)
+			}
+			d.Set("project", value.Key)
 			d.Set("visibility", value.Visibility)
+			d.Set("qualifier", value.Qualifier)
+			d.Set("last_analysis_date", value.LastAnalysisDate)
+			d.Set("revision", value.Revision)
+			// SonarQube has no organizations
+			if value.Organization != "" {
+				d.Set("organization", value.Organization)
+			}
 			readSuccess = true
 		}
 	}
@@ -122,37 +147,91 @@ func resourceSonarcloudProjectRead(d *schema.ResourceData, m interface{}) error
 	if !readSuccess {
 		// Project not found
 		d.SetId("")
+		return nil
+	}
+
+	// The search results do not include the tags
+	componentResponse, err := m.(*ProviderConfiguration).client.Components.Show(ctx, d.Id())
+	if err != nil {
+		return diag.FromErr(err)
 	}
+	d.Set("tags", componentResponse.Component.Tags)
 
 	return nil
 }
 
-func resourceSonarcloudProjectDelete(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/projects/delete"
-	sonarCloudURL.RawQuery = url.Values{
-		"project": []string{d.Id()},
-                "organization": []string{d.Get("organization").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusNoContent,
-		"resourceSonarcloudProjectDelete",
-	)
-	if err != nil {
+func resourceSonarcloudProjectUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	sonarcloudClient := m.(*ProviderConfiguration).client
+
+	// Change the key first, so the visibility is updated on the new key
+	if d.HasChange("project") {
+		_, newKey := d.GetChange("project")
+		if err := sonarcloudClient.Projects.UpdateKey(ctx, d.Id(), newKey.(string)); err != nil {
+			return diag.FromErr(err)
+		}
+		d.SetId(newKey.(string))
+	}
+
+	if d.HasChange("visibility") {
+		if err := sonarcloudClient.Projects.UpdateVisibility(ctx, d.Id(), d.Get("visibility").(string)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if d.HasChange("tags") {
+		if err := sonarcloudClient.ProjectTags.Set(ctx, d.Id(), expandTags(d)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return resourceSonarcloudProjectRead(ctx, d, m)
+}
+
+func resourceSonarcloudProjectDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	return diag.FromErr(m.(*ProviderConfiguration).client.Projects.Delete(ctx, d.Id()))
+}
+
+func resourceSonarcloudProjectImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	// The ID is either the project key, or <organization>/<project key> for
+	// a project outside the default organization of the provider. Project
+	// keys can not contain a slash.
+	id := d.Id()
+	if organization, key, ok := strings.Cut(id, "/"); ok {
+		if organization == "" || key == "" {
+			return nil, fmt.Errorf("Unexpected format of ID %q, expected <project key> or <organization>/<project key>", id)
+		}
+		d.Set("organization", organization)
+		d.SetId(key)
+	}
+
+	if diags := resourceSonarcloudProjectRead(ctx, d, m); diags.HasError() {
+		return nil, diagnosticsError(diags)
+	}
+	if d.Id() == "" {
+		return nil, fmt.Errorf("Project %q not found", id)
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceSonarcloudProjectCustomizeDiff checks that the organization is known,
+// and that the name of an existing project is not changed.
+func resourceSonarcloudProjectCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if err := requireOrganization(ctx, d, m); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
+	if d.Id() != "" && d.NewValueKnown("name") && d.HasChange("name") {
+		return fmt.Errorf("Project %q can not be renamed through the web API, the analysis sets its name through sonar.projectName", d.Id())
+	}
 	return nil
 }
 
-func resourceSonarcloudProjectImport(d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
-	if err := resourceSonarcloudProjectRead(d, m); err != nil {
-		return nil, err
+func expandTags(d *schema.ResourceData) []string {
+	expandedTags := make([]string, 0)
+	for _, tag := range d.Get("tags").(*schema.Set).List() {
+		expandedTags = append(expandedTags, tag.(string))
 	}
-	return []*schema.ResourceData{d}, nil
+	sort.Strings(expandedTags)
+
+	return expandedTags
 }
@@ -0,0 +1,171 @@
+package sonarcloud
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/meetdpv/SonarCloud/tests/sonarcloudtest"
+)
+
+func TestAccSonarcloudProject(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_project"),
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_project" "test" {
+  name    = "Billing"
+  project = "billing"
+  tags    = ["backend", "java"]
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_project.test", "id", "billing"),
+					resource.TestCheckResourceAttr("sonarcloud_project.test", "name", "Billing"),
+					resource.TestCheckResourceAttr("sonarcloud_project.test", "visibility", "public"),
+					resource.TestCheckResourceAttr("sonarcloud_project.test", "qualifier", "TRK"),
+					resource.TestCheckResourceAttr("sonarcloud_project.test", "tags.#", "2"),
+					resource.TestCheckTypeSetElemAttr("sonarcloud_project.test", "tags.*", "backend"),
+					resource.TestCheckTypeSetElemAttr("sonarcloud_project.test", "tags.*", "java"),
+				),
+			},
+			{
+				// The key, visibility and tags change in place
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_project" "test" {
+  name       = "Billing"
+  project    = "billing-service"
+  visibility = "private"
+  tags       = ["backend"]
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_project.test", "id", "billing-service"),
+					resource.TestCheckResourceAttr("sonarcloud_project.test", "project", "billing-service"),
+					resource.TestCheckResourceAttr("sonarcloud_project.test", "visibility", "private"),
+					resource.TestCheckResourceAttr("sonarcloud_project.test", "tags.#", "1"),
+				),
+			},
+			{
+				// The name is set by the analysis, a rename is refused
+				// before anything else is changed
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_project" "test" {
+  name       = "Billing service"
+  project    = "billing-service"
+  visibility = "public"
+  tags       = ["backend"]
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`can not be renamed through the web API`),
+			},
+			{
+				// A name set by the analysis is not a change
+				PreConfig: func() {
+					server.AnalyzeProject("billing-service", "Billing service", "c0ffee")
+				},
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_project" "test" {
+  name       = "Billing"
+  project    = "billing-service"
+  visibility = "public"
+  tags       = ["backend"]
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_project.test", "name", "Billing"),
+					resource.TestCheckResourceAttr("sonarcloud_project.test", "visibility", "public"),
+					resource.TestCheckResourceAttr("sonarcloud_project.test", "revision", "c0ffee"),
+				),
+			},
+			{
+				ResourceName:      "sonarcloud_project.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+				// An imported project gets the name set by the analysis
+				ImportStateVerifyIgnore: []string{"name"},
+			},
+		},
+	})
+}
+
+func TestAccSonarcloudProjectOrganization(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{Platform: sonarcloudtest.PlatformSonarCloud})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_project"),
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig(`organization = "my-org"`) + `
+resource "sonarcloud_project" "test" {
+  name    = "Billing"
+  project = "my-org_billing"
+}
+
+resource "sonarcloud_project" "other" {
+  name         = "Shop"
+  project      = "other-org_shop"
+  organization = "other-org"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_project.test", "organization", "my-org"),
+					resource.TestCheckResourceAttr("sonarcloud_project.other", "organization", "other-org"),
+				),
+			},
+			{
+				ResourceName:      "sonarcloud_project.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "sonarcloud_project.other",
+				ImportState:       true,
+				ImportStateId:     "other-org/other-org_shop",
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccSonarcloudProjectTagsDataSource(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_project" "test" {
+  name    = "Billing"
+  project = "billing"
+  tags    = ["backend", "java", "legacy"]
+}
+
+data "sonarcloud_project_tags" "all" {
+  depends_on = [sonarcloud_project.test]
+}
+
+data "sonarcloud_project_tags" "search" {
+  search     = "ja"
+  depends_on = [sonarcloud_project.test]
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.sonarcloud_project_tags.all", "tags.#", "3"),
+					resource.TestCheckResourceAttr("data.sonarcloud_project_tags.all", "tags.0", "backend"),
+					resource.TestCheckResourceAttr("data.sonarcloud_project_tags.all", "tags.1", "java"),
+					resource.TestCheckResourceAttr("data.sonarcloud_project_tags.all", "tags.2", "legacy"),
+					resource.TestCheckResourceAttr("data.sonarcloud_project_tags.search", "tags.#", "1"),
+					resource.TestCheckResourceAttr("data.sonarcloud_project_tags.search", "tags.0", "java"),
+				),
+			},
+		},
+	})
+}
@@ -1,23 +1,31 @@
 package sonarcloud
 
 import (
-	"encoding/json"
-	"net/http"
-	"net/url"
+	"context"
+	"fmt"
 	"strconv"
 
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
-	log "github.com/sirupsen/logrus"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/meetdpv/SonarCloud/sonarcloud/client"
 )
 
 // Returns the resource represented by this file.
 func resourceSonarcloudQualityGate() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceSonarcloudQualityGateCreate,
-		Read:   resourceSonarcloudQualityGateRead,
-		Delete: resourceSonarcloudQualityGateDelete,
+		CreateContext: resourceSonarcloudQualityGateCreate,
+		ReadContext:   resourceSonarcloudQualityGateRead,
+		UpdateContext: resourceSonarcloudQualityGateUpdate,
+		DeleteContext: resourceSonarcloudQualityGateDelete,
 		Importer: &schema.ResourceImporter{
-			State: resourceSonarcloudQualityGateImport,
+			StateContext: resourceSonarcloudQualityGateImport,
+		},
+		CustomizeDiff: resourceSonarcloudQualityGateCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultTimeout),
+			Read:   schema.DefaultTimeout(defaultTimeout),
+			Update: schema.DefaultTimeout(defaultTimeout),
+			Delete: schema.DefaultTimeout(defaultTimeout),
 		},
 
 		// Define the fields of this schema.
@@ -25,105 +33,180 @@ func resourceSonarcloudQualityGate() *schema.Resource {
 			"name": {
 				Type:     schema.TypeString,
 				Required: true,
+			},
+			"organization": {
+				Type:     schema.TypeString,
+				Optional: true,
 				ForceNew: true,
 			},
+			"copy_from": {
+				Type:     schema.TypeString,
+				Optional: true,
+				// The conditions are only copied when the quality gate is
+				// created, changing the source afterwards has no effect
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return d.Id() != ""
+				},
+			},
+			"is_default": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Computed: true,
+			},
+			"is_built_in": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
 		},
 	}
 }
 
-func resourceSonarcloudQualityGateCreate(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/qualitygates/create"
-	sonarCloudURL.RawQuery = url.Values{
-		"name": []string{d.Get("name").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceQualityGateCreate",
-	)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	// Decode response into struct
-	qualityGateResponse := CreateQualityGateResponse{}
-	err = json.NewDecoder(resp.Body).Decode(&qualityGateResponse)
-	if err != nil {
-		log.WithError(err).Error("resourceQualityGateCreate: Failed to decode json into struct")
+func resourceSonarcloudQualityGateCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var qualityGateResponse *client.CreateQualityGateResponse
+	var err error
+	if copyFrom := d.Get("copy_from").(string); copyFrom != "" {
+		qualityGateResponse, err = copyQualityGate(ctx, d, m, copyFrom)
+		if err != nil {
+			return attributeError("copy_from", err)
+		}
+	} else {
+		qualityGateResponse, err = m.(*ProviderConfiguration).client.QualityGates.Create(ctx,
+			d.Get("name").(string),
+			getOrganization(d, m),
+		)
+		if err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
 	d.SetId(strconv.FormatInt(qualityGateResponse.ID, 10))
-	return nil
-}
 
-func resourceSonarcloudQualityGateRead(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/qualitygates/show"
-	sonarCloudURL.RawQuery = url.Values{
-		"id": []string{d.Id()},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"GET",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceQualityGateRead",
-	)
-	if err != nil {
-		return err
+	if d.Get("is_default").(bool) {
+		if diags := setDefaultQualityGate(ctx, d, m); diags.HasError() {
+			return diags
+		}
 	}
-	defer resp.Body.Close()
+	return resourceSonarcloudQualityGateRead(ctx, d, m)
+}
 
-	// Decode response into struct
-	qualityGateReadResponse := GetQualityGate{}
-	err = json.NewDecoder(resp.Body).Decode(&qualityGateReadResponse)
+func resourceSonarcloudQualityGateRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	qualityGateReadResponse, err := m.(*ProviderConfiguration).client.QualityGates.Show(ctx, d.Id(), getOrganization(d, m))
+	if isNotFound(err) {
+		// Quality gate not found
+		d.SetId("")
+		return nil
+	}
 	if err != nil {
-		log.WithError(err).Error("resourceQualityGateRead: Failed to decode json into struct")
+		return diag.FromErr(err)
 	}
 
 	d.SetId(strconv.FormatInt(qualityGateReadResponse.ID, 10))
 	d.Set("name", qualityGateReadResponse.Name)
+	d.Set("is_built_in", qualityGateReadResponse.IsBuiltIn)
+
+	qualityGatesResponse, err := m.(*ProviderConfiguration).client.QualityGates.List(ctx, getOrganization(d, m))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	isDefault := false
+	for _, qualityGate := range qualityGatesResponse.QualityGates {
+		if qualityGate.ID == qualityGateReadResponse.ID {
+			isDefault = qualityGate.IsDefault
+		}
+	}
+	d.Set("is_default", isDefault)
 	return nil
 }
 
-func resourceSonarcloudQualityGateDelete(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/qualitygates/destroy"
-	sonarCloudURL.RawQuery = url.Values{
-		"id": []string{d.Id()},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusNoContent,
-		"resourceQualityGateDelete",
-	)
-	if err != nil {
-		return err
+func resourceSonarcloudQualityGateUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfiguration)
+
+	// Keep the old values in the state when a change is refused, otherwise
+	// it would not show up in the next plan
+	if d.HasChange("name") {
+		qualityGate, err := config.client.QualityGates.Show(ctx, d.Id(), getOrganization(d, m))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if !qualityGate.Actions.Rename {
+			d.Partial(true)
+			return attributeError("name", fmt.Errorf("Quality gate %q can not be renamed: it is built-in or you lack the permission to administer quality gates", qualityGate.Name))
+		}
+
+		err = config.client.QualityGates.Rename(ctx, d.Id(), d.Get("name").(string), getOrganization(d, m))
+		if err != nil {
+			return diag.FromErr(err)
+		}
 	}
-	defer resp.Body.Close()
 
-	// Decode response into struct
-	qualityGateReadResponse := GetQualityGate{}
-	err = json.NewDecoder(resp.Body).Decode(&qualityGateReadResponse)
-	if err != nil {
-		log.WithError(err).Error("resourceQualityGateDelete: Failed to decode json into struct")
+	if d.HasChange("is_default") {
+		if diags := setDefaultQualityGate(ctx, d, m); diags.HasError() {
+			return diags
+		}
+	}
+
+	return resourceSonarcloudQualityGateRead(ctx, d, m)
+}
+
+// resourceSonarcloudQualityGateCustomizeDiff checks that the organization is
+// known, and that an existing default quality gate is not unset.
+func resourceSonarcloudQualityGateCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if err := requireOrganization(ctx, d, m); err != nil {
+		return err
 	}
 
+	if d.Id() != "" && d.HasChange("is_default") {
+		if old, new := d.GetChange("is_default"); old.(bool) && !new.(bool) {
+			return fmt.Errorf("Quality gate %q can not stop being the default, set another quality gate as default instead", d.Get("name").(string))
+		}
+	}
 	return nil
 }
 
-func resourceSonarcloudQualityGateImport(d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
-	if err := resourceSonarcloudQualityGateRead(d, m); err != nil {
-		return nil, err
+func resourceSonarcloudQualityGateDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	return diag.FromErr(m.(*ProviderConfiguration).client.QualityGates.Destroy(ctx, d.Id(), getOrganization(d, m)))
+}
+
+func resourceSonarcloudQualityGateImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	if diags := resourceSonarcloudQualityGateRead(ctx, d, m); diags.HasError() {
+		return nil, diagnosticsError(diags)
 	}
 	return []*schema.ResourceData{d}, nil
 }
+
+// copyQualityGate creates the quality gate of the resource as a copy of the
+// quality gate named source, e.g. the built-in "Sonar way".
+func copyQualityGate(ctx context.Context, d *schema.ResourceData, m interface{}, source string) (*client.CreateQualityGateResponse, error) {
+	config := m.(*ProviderConfiguration)
+	organization := getOrganization(d, m)
+
+	qualityGatesResponse, err := config.client.QualityGates.List(ctx, organization)
+	if err != nil {
+		return nil, err
+	}
+	for _, qualityGate := range qualityGatesResponse.QualityGates {
+		if qualityGate.Name != source {
+			continue
+		}
+		if !qualityGate.Actions.Copy {
+			return nil, fmt.Errorf("Quality gate %q can not be copied: you lack the permission to administer quality gates", source)
+		}
+		return config.client.QualityGates.Copy(ctx, strconv.FormatInt(qualityGate.ID, 10), d.Get("name").(string), organization)
+	}
+	return nil, fmt.Errorf("Quality gate %q to copy from not found", source)
+}
+
+// setDefaultQualityGate makes the quality gate of the resource the default.
+func setDefaultQualityGate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	config := m.(*ProviderConfiguration)
+
+	qualityGate, err := config.client.QualityGates.Show(ctx, d.Id(), getOrganization(d, m))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if !qualityGate.Actions.SetAsDefault {
+		return attributeError("is_default", fmt.Errorf("Quality gate %q can not be set as default: you lack the permission to administer quality gates", qualityGate.Name))
+	}
+
+	return diag.FromErr(config.client.QualityGates.SetAsDefault(ctx, d.Id(), getOrganization(d, m)))
+}
@@ -1,22 +1,28 @@
 package sonarcloud
 
 import (
-	"encoding/json"
-	"net/http"
-	"net/url"
+	"context"
 	"strconv"
 
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
-	log "github.com/sirupsen/logrus"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/meetdpv/SonarCloud/sonarcloud/client"
 )
 
 // Returns the resource represented by this file.
 func resourceSonarcloudQualityGateCondition() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceSonarcloudQualityGateConditionCreate,
-		Read:   resourceSonarcloudQualityGateConditionRead,
-		Update: resourceSonarcloudQualityGateConditionUpdate,
-		Delete: resourceSonarcloudQualityGateConditionDelete,
+		CreateContext: resourceSonarcloudQualityGateConditionCreate,
+		ReadContext:   resourceSonarcloudQualityGateConditionRead,
+		UpdateContext: resourceSonarcloudQualityGateConditionUpdate,
+		DeleteContext: resourceSonarcloudQualityGateConditionDelete,
+		CustomizeDiff: requireOrganization,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultTimeout),
+			Read:   schema.DefaultTimeout(defaultTimeout),
+			Update: schema.DefaultTimeout(defaultTimeout),
+			Delete: schema.DefaultTimeout(defaultTimeout),
+		},
 
 		// Define the fields of this schema.
 		Schema: map[string]*schema.Schema{
@@ -26,7 +32,8 @@ func resourceSonarcloudQualityGateCondition() *schema.Resource {
 				ForceNew: true,
 			},
 			"error": {
-				Type:     schema.TypeInt,
+				// Thresholds of ratings and percentages can be decimals
+				Type:     schema.TypeFloat,
 				Required: true,
 			},
 			"metric": {
@@ -37,126 +44,92 @@ func resourceSonarcloudQualityGateCondition() *schema.Resource {
 				Type:     schema.TypeString,
 				Required: true,
 			},
+			"organization": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
 		},
 	}
 }
 
-func resourceSonarcloudQualityGateConditionCreate(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/qualitygates/create_condition"
-	sonarCloudURL.RawQuery = url.Values{
-		"gateId": []string{strconv.Itoa(d.Get("gateid").(int))},
-		"error":  []string{strconv.Itoa(d.Get("error").(int))},
-		"metric": []string{d.Get("metric").(string)},
-		"op":     []string{d.Get("op").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourcequalityGateConditionCreate",
-	)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	// Decode response into struct
-	qualityGateConditionResponse := CreateQualityGateConditionResponse{}
-	err = json.NewDecoder(resp.Body).Decode(&qualityGateConditionResponse)
+func resourceSonarcloudQualityGateConditionCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	qualityGateConditionResponse, err := m.(*ProviderConfiguration).client.QualityGates.CreateCondition(ctx, client.QualityGateConditionOptions{
+		GateID:       strconv.Itoa(d.Get("gateid").(int)),
+		Error:        formatThreshold(d.Get("error").(float64)),
+		Metric:       d.Get("metric").(string),
+		OP:           d.Get("op").(string),
+		Organization: getOrganization(d, m),
+	})
 	if err != nil {
-		log.WithError(err).Error("getQualityGateConditionResponse: Failed to decode json into struct")
+		return diag.FromErr(err)
 	}
 
 	d.SetId(strconv.FormatInt(qualityGateConditionResponse.ID, 10))
 	return nil
 }
 
-func resourceSonarcloudQualityGateConditionRead(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/qualitygates/show"
-	sonarCloudURL.RawQuery = url.Values{
-		"id": []string{strconv.Itoa(d.Get("gateid").(int))},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"GET",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourcequalityGateConditionRead",
+func resourceSonarcloudQualityGateConditionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	getQualityGateConditionResponse, err := m.(*ProviderConfiguration).client.QualityGates.Show(ctx,
+		strconv.Itoa(d.Get("gateid").(int)),
+		getOrganization(d, m),
 	)
-	if err != nil {
-		return err
+	if isNotFound(err) {
+		// Quality gate not found
+		d.SetId("")
+		return nil
 	}
-	defer resp.Body.Close()
-
-	// Decode response into struct
-	getQualityGateConditionResponse := GetQualityGate{}
-	err = json.NewDecoder(resp.Body).Decode(&getQualityGateConditionResponse)
 	if err != nil {
-		log.WithError(err).Error("getQualityGateConditionResponse: Failed to decode json into struct")
+		return diag.FromErr(err)
 	}
 
+	readSuccess := false
 	for _, value := range getQualityGateConditionResponse.Conditions {
 		if d.Id() == strconv.FormatInt(value.ID, 10) {
+			// The API returns the threshold as a string
+			threshold, err := strconv.ParseFloat(value.Error, 64)
+			if err != nil {
+				return diag.Errorf("Unexpected threshold %q of quality gate condition %s: %+v", value.Error, d.Id(), err)
+			}
+
 			d.SetId(strconv.FormatInt(value.ID, 10))
 			d.Set("gateid", getQualityGateConditionResponse.ID)
-			d.Set("error", value.Error)
+			d.Set("error", threshold)
 			d.Set("metric", value.Metric)
 			d.Set("op", value.OP)
+			readSuccess = true
 		}
 	}
 
+	if !readSuccess {
+		// Condition not found
+		d.SetId("")
+	}
+
 	return nil
 }
 
-func resourceSonarcloudQualityGateConditionUpdate(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/qualitygates/update_condition"
-	sonarCloudURL.RawQuery = url.Values{
-		"gateid": []string{strconv.Itoa(d.Get("gateid").(int))},
-		"id":     []string{d.Id()},
-		"error":  []string{strconv.Itoa(d.Get("error").(int))},
-		"metric": []string{d.Get("metric").(string)},
-		"op":     []string{d.Get("op").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourcequalityGateConditionUpdate",
-	)
+func resourceSonarcloudQualityGateConditionUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	err := m.(*ProviderConfiguration).client.QualityGates.UpdateCondition(ctx, client.QualityGateConditionOptions{
+		ID:           d.Id(),
+		Error:        formatThreshold(d.Get("error").(float64)),
+		Metric:       d.Get("metric").(string),
+		OP:           d.Get("op").(string),
+		Organization: getOrganization(d, m),
+	})
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
-	defer resp.Body.Close()
 
-	return resourceSonarcloudQualityGateConditionRead(d, m)
+	return resourceSonarcloudQualityGateConditionRead(ctx, d, m)
 }
 
-func resourceSonarcloudQualityGateConditionDelete(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/qualitygates/delete_condition"
-	sonarCloudURL.RawQuery = url.Values{
-		"id": []string{d.Id()},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusNoContent,
-		"resourcequalityGateConditionDelete",
-	)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+func resourceSonarcloudQualityGateConditionDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	return diag.FromErr(m.(*ProviderConfiguration).client.QualityGates.DeleteCondition(ctx, d.Id(), getOrganization(d, m)))
+}
 
-	return nil
+// formatThreshold formats the threshold of a condition without trailing zeros,
+// e.g. 80 instead of 80.000000.
+func formatThreshold(threshold float64) string {
+	return strconv.FormatFloat(threshold, 'f', -1, 64)
 }
@@ -0,0 +1,103 @@
+package sonarcloud
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/meetdpv/SonarCloud/tests/sonarcloudtest"
+)
+
+func testAccSonarcloudQualityGateConditionConfig(server *sonarcloudtest.Server, condition string) string {
+	return server.ProviderConfig() + `
+resource "sonarcloud_qualitygate" "test" {
+  name = "Strict"
+}
+` + condition
+}
+
+func TestAccSonarcloudQualityGateCondition(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_qualitygate_condition"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSonarcloudQualityGateConditionConfig(server, `
+resource "sonarcloud_qualitygate_condition" "test" {
+  gateid = sonarcloud_qualitygate.test.id
+  metric = "new_coverage"
+  op     = "LT"
+  error  = 80
+}
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("sonarcloud_qualitygate_condition.test", "id"),
+					resource.TestCheckResourceAttrPair("sonarcloud_qualitygate_condition.test", "gateid", "sonarcloud_qualitygate.test", "id"),
+					resource.TestCheckResourceAttr("sonarcloud_qualitygate_condition.test", "metric", "new_coverage"),
+					resource.TestCheckResourceAttr("sonarcloud_qualitygate_condition.test", "op", "LT"),
+					resource.TestCheckResourceAttr("sonarcloud_qualitygate_condition.test", "error", "80"),
+				),
+			},
+			{
+				Config: testAccSonarcloudQualityGateConditionConfig(server, `
+resource "sonarcloud_qualitygate_condition" "test" {
+  gateid = sonarcloud_qualitygate.test.id
+  metric = "new_duplicated_lines_density"
+  op     = "GT"
+  error  = 3
+}
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_qualitygate_condition.test", "metric", "new_duplicated_lines_density"),
+					resource.TestCheckResourceAttr("sonarcloud_qualitygate_condition.test", "op", "GT"),
+					resource.TestCheckResourceAttr("sonarcloud_qualitygate_condition.test", "error", "3"),
+				),
+			},
+			{
+				Config: testAccSonarcloudQualityGateConditionConfig(server, `
+resource "sonarcloud_qualitygate_condition" "test" {
+  gateid = sonarcloud_qualitygate.test.id
+  metric = "new_duplicated_lines_density"
+  op     = "GT"
+  error  = 1.5
+}
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_qualitygate_condition.test", "error", "1.5"),
+					// The threshold is sent without trailing zeros
+					func(s *terraform.State) error {
+						provider := Provider()
+						diags := provider.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+							"url":      server.BaseURL(),
+							"token":    "sonarcloudtest",
+							"platform": server.Platform(),
+						}))
+						if diags.HasError() {
+							return fmt.Errorf("configuring the provider: %v", diags)
+						}
+						gate, err := provider.Meta().(*ProviderConfiguration).client.QualityGates.Show(context.Background(),
+							s.RootModule().Resources["sonarcloud_qualitygate.test"].Primary.ID, "")
+						if err != nil {
+							return err
+						}
+						if len(gate.Conditions) != 1 || gate.Conditions[0].Error != "1.5" {
+							return fmt.Errorf("expected the threshold 1.5, got %+v", gate.Conditions)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				ResourceName:  "sonarcloud_qualitygate_condition.test",
+				ImportState:   true,
+				ImportStateId: "1",
+				ExpectError:   regexp.MustCompile(`doesn't support import`),
+			},
+		},
+	})
+}
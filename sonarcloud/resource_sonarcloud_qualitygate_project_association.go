@@ -1,28 +1,39 @@
 package sonarcloud
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
-	"net/url"
 	"strings"
 
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
-	log "github.com/sirupsen/logrus"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/meetdpv/SonarCloud/sonarcloud/client"
 )
 
 // Returns the resource represented by this file.
 func resourceSonarcloudQualityGateProjectAssociation() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceSonarcloudQualityGateProjectAssociationCreate,
-		Read:   resourceSonarcloudQualityGateProjectAssociationRead,
-		Delete: resourceSonarcloudQualityGateProjectAssociationDelete,
+		CreateContext: resourceSonarcloudQualityGateProjectAssociationCreate,
+		ReadContext:   resourceSonarcloudQualityGateProjectAssociationRead,
+		DeleteContext: resourceSonarcloudQualityGateProjectAssociationDelete,
+		CustomizeDiff: resourceSonarcloudQualityGateProjectAssociationCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultTimeout),
+			Read:   schema.DefaultTimeout(defaultTimeout),
+			Delete: schema.DefaultTimeout(defaultTimeout),
+		},
 
 		// Define the fields of this schema.
 		Schema: map[string]*schema.Schema{
 			"gateid": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"gateid", "gatename"},
+			},
+			"gatename": {
 				Type:     schema.TypeString,
-				Required: true,
+				Optional: true,
 				ForceNew: true,
 			},
 			"projectkey": {
@@ -30,94 +41,99 @@ func resourceSonarcloudQualityGateProjectAssociation() *schema.Resource {
 				Required: true,
 				ForceNew: true,
 			},
+			"organization": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
 		},
 	}
 }
 
-func resourceSonarcloudQualityGateProjectAssociationCreate(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/qualitygates/select"
-	sonarCloudURL.RawQuery = url.Values{
-		"gateId":     []string{d.Get("gateid").(string)},
-		"projectKey": []string{d.Get("projectkey").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusNoContent,
-		"resourceSonarcloudQualityGateProjectAssociationCreate",
+func resourceSonarcloudQualityGateProjectAssociationCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	err := m.(*ProviderConfiguration).client.QualityGates.Select(ctx,
+		qualityGateRef(d),
+		d.Get("projectkey").(string),
+		getOrganization(d, m),
 	)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
-	defer resp.Body.Close()
 
-	id := fmt.Sprintf("%v/%v", d.Get("gateid").(string), d.Get("projectkey").(string))
+	gate := d.Get("gateid").(string)
+	if gate == "" {
+		gate = d.Get("gatename").(string)
+	}
+	id := fmt.Sprintf("%v/%v", gate, d.Get("projectkey").(string))
 	d.SetId(id)
 	return nil
 }
 
-func resourceSonarcloudQualityGateProjectAssociationRead(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/qualitygates/search"
-	sonarCloudURL.RawQuery = url.Values{
-		"gateId": []string{d.Get("gateid").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"GET",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceSonarcloudQualityGateProjectAssociationRead",
+func resourceSonarcloudQualityGateProjectAssociationRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	qualityGateAssociationReadResponse, err := m.(*ProviderConfiguration).client.QualityGates.Search(ctx,
+		qualityGateRef(d),
+		getOrganization(d, m),
 	)
-	if err != nil {
-		return err
+	if isNotFound(err) {
+		// Quality gate not found
+		d.SetId("")
+		return nil
 	}
-	defer resp.Body.Close()
-
-	// Decode response into struct
-	qualityGateAssociationReadResponse := GetQualityGateAssociation{}
-	err = json.NewDecoder(resp.Body).Decode(&qualityGateAssociationReadResponse)
 	if err != nil {
-		log.WithError(err).Error("resourceSonarcloudQualityGateProjectAssociationRead: Failed to decode json into struct")
+		return diag.FromErr(err)
 	}
 
-	// ID is in format <gateid>/<projectkey>. This splits the id into gateid and projectkey
-	// EG: "1/my_project" >> ["1", "my_project"]
-	idSlice := strings.Split(d.Id(), "/")
+	// ID is in format <gateid>/<projectkey> or <gatename>/<projectkey>. Project
+	// keys can not contain a slash, gate names can.
+	// EG: "1/my_project" >> "1", "my_project"
+	separator := strings.LastIndex(d.Id(), "/")
+	projectKey := d.Id()[separator+1:]
 
+	readSuccess := false
 	for _, value := range qualityGateAssociationReadResponse.Results {
-		if idSlice[1] == value.Key {
-			d.Set("gateid", idSlice[0])
+		if projectKey == value.Key {
 			d.Set("projectkey", value.Key)
+			readSuccess = true
 		}
 	}
 
+	if !readSuccess {
+		// The project no longer uses the quality gate
+		d.SetId("")
+	}
+
 	return nil
 }
 
-func resourceSonarcloudQualityGateProjectAssociationDelete(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/qualitygates/deselect"
-	sonarCloudURL.RawQuery = url.Values{
-		"gateId":     []string{d.Get("gateid").(string)},
-		"projectKey": []string{d.Get("projectkey").(string)},
-	}.Encode()
+func resourceSonarcloudQualityGateProjectAssociationDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	return diag.FromErr(m.(*ProviderConfiguration).client.QualityGates.Deselect(ctx,
+		qualityGateRef(d),
+		d.Get("projectkey").(string),
+		getOrganization(d, m),
+	))
+}
 
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusNoContent,
-		"resourceSonarcloudQualityGateProjectAssociationDelete",
-	)
-	if err != nil {
+// resourceSonarcloudQualityGateProjectAssociationCustomizeDiff checks that the
+// server can identify the quality gate by name when gatename is used.
+func resourceSonarcloudQualityGateProjectAssociationCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if err := requireOrganization(ctx, d, m); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	if m == nil {
+		return nil
+	}
 
+	if _, ok := d.GetOk("gatename"); ok {
+		return m.(*ProviderConfiguration).requireCapability(capabilityQualityGateByName)
+	}
 	return nil
 }
+
+// qualityGateRef returns the quality gate of the association, by id or by
+// name.
+func qualityGateRef(d *schema.ResourceData) client.QualityGateRef {
+	return client.QualityGateRef{
+		ID:   d.Get("gateid").(string),
+		Name: d.Get("gatename").(string),
+	}
+}
@@ -0,0 +1,98 @@
+package sonarcloud
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/meetdpv/SonarCloud/tests/sonarcloudtest"
+)
+
+func testAccSonarcloudQualityGateProjectAssociationConfig(server *sonarcloudtest.Server, association string) string {
+	return server.ProviderConfig() + `
+resource "sonarcloud_qualitygate" "test" {
+  name = "Strict"
+}
+
+resource "sonarcloud_project" "billing" {
+  name    = "Billing"
+  project = "billing"
+}
+
+resource "sonarcloud_project" "shop" {
+  name    = "Shop"
+  project = "shop"
+}
+` + association
+}
+
+func TestAccSonarcloudQualityGateProjectAssociation(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_qualitygate_project_association"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSonarcloudQualityGateProjectAssociationConfig(server, `
+resource "sonarcloud_qualitygate_project_association" "test" {
+  gateid     = sonarcloud_qualitygate.test.id
+  projectkey = sonarcloud_project.billing.project
+}
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("sonarcloud_qualitygate_project_association.test", "gateid", "sonarcloud_qualitygate.test", "id"),
+					resource.TestCheckResourceAttr("sonarcloud_qualitygate_project_association.test", "projectkey", "billing"),
+				),
+			},
+			{
+				// Another project replaces the association
+				Config: testAccSonarcloudQualityGateProjectAssociationConfig(server, `
+resource "sonarcloud_qualitygate_project_association" "test" {
+  gateid     = sonarcloud_qualitygate.test.id
+  projectkey = sonarcloud_project.shop.project
+}
+`),
+				Check: resource.TestCheckResourceAttr("sonarcloud_qualitygate_project_association.test", "projectkey", "shop"),
+			},
+			{
+				// The quality gate can also be identified by name
+				Config: testAccSonarcloudQualityGateProjectAssociationConfig(server, `
+resource "sonarcloud_qualitygate_project_association" "test" {
+  gatename   = sonarcloud_qualitygate.test.name
+  projectkey = sonarcloud_project.shop.project
+}
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_qualitygate_project_association.test", "id", "Strict/shop"),
+					resource.TestCheckResourceAttr("sonarcloud_qualitygate_project_association.test", "gatename", "Strict"),
+				),
+			},
+			{
+				ResourceName:  "sonarcloud_qualitygate_project_association.test",
+				ImportState:   true,
+				ImportStateId: "Strict/shop",
+				ExpectError:   regexp.MustCompile(`doesn't support import`),
+			},
+		},
+	})
+}
+
+func TestAccSonarcloudQualityGateProjectAssociationByNameUnsupported(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{Version: "8.3.1.34397"})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSonarcloudQualityGateProjectAssociationConfig(server, `
+resource "sonarcloud_qualitygate_project_association" "test" {
+  gatename   = sonarcloud_qualitygate.test.name
+  projectkey = sonarcloud_project.billing.project
+}
+`),
+				ExpectError: regexp.MustCompile(`requires server version >= 8.4.0`),
+			},
+		},
+	})
+}
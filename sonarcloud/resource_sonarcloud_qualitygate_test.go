@@ -0,0 +1,119 @@
+package sonarcloud
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/meetdpv/SonarCloud/tests/sonarcloudtest"
+)
+
+func TestAccSonarcloudQualityGate(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_qualitygate"),
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_qualitygate" "test" {
+  name = "Strict"
+}
+
+resource "sonarcloud_qualitygate" "copy" {
+  name      = "Sonar way copy"
+  copy_from = "Sonar way"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("sonarcloud_qualitygate.test", "id"),
+					resource.TestCheckResourceAttr("sonarcloud_qualitygate.test", "name", "Strict"),
+					resource.TestCheckResourceAttr("sonarcloud_qualitygate.test", "is_default", "false"),
+					resource.TestCheckResourceAttr("sonarcloud_qualitygate.test", "is_built_in", "false"),
+					resource.TestCheckResourceAttr("sonarcloud_qualitygate.copy", "name", "Sonar way copy"),
+					resource.TestCheckResourceAttr("sonarcloud_qualitygate.copy", "is_built_in", "false"),
+				),
+			},
+			{
+				// The name and default change in place
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_qualitygate" "test" {
+  name       = "Very strict"
+  is_default = true
+}
+
+resource "sonarcloud_qualitygate" "copy" {
+  name      = "Sonar way copy"
+  copy_from = "Sonar way"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_qualitygate.test", "name", "Very strict"),
+					resource.TestCheckResourceAttr("sonarcloud_qualitygate.test", "is_default", "true"),
+				),
+			},
+			{
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_qualitygate" "test" {
+  name       = "Very strict"
+  is_default = false
+}
+
+resource "sonarcloud_qualitygate" "copy" {
+  name      = "Sonar way copy"
+  copy_from = "Sonar way"
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`can not stop being the default`),
+			},
+			{
+				ResourceName:      "sonarcloud_qualitygate.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "sonarcloud_qualitygate.copy",
+				ImportState:       true,
+				ImportStateVerify: true,
+				// Only the conditions are copied, the source is not known
+				ImportStateVerifyIgnore: []string{"copy_from"},
+			},
+			{
+				// The default quality gate can not be removed
+				PreConfig: server.ResetDefaultQualityGate,
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_qualitygate" "test" {
+  name = "Very strict"
+}
+
+resource "sonarcloud_qualitygate" "copy" {
+  name      = "Sonar way copy"
+  copy_from = "Sonar way"
+}
+`,
+				Check: resource.TestCheckResourceAttr("sonarcloud_qualitygate.test", "is_default", "false"),
+			},
+		},
+	})
+}
+
+func TestAccSonarcloudQualityGateCopyFromUnknown(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_qualitygate" "test" {
+  name      = "Strict"
+  copy_from = "Unknown"
+}
+`,
+				ExpectError: regexp.MustCompile(`Quality gate "Unknown" to copy from not found`),
+			},
+		},
+	})
+}
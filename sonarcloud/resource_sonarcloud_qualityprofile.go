@@ -1,23 +1,37 @@
 package sonarcloud
 
 import (
-	"encoding/json"
-	"net/http"
-	"net/url"
-	"strconv"
+	"context"
+	"fmt"
 
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
-	log "github.com/sirupsen/logrus"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/meetdpv/SonarCloud/sonarcloud/client"
 )
 
 // Returns the resource represented by this file.
 func resourceSonarcloudQualityProfile() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceSonarcloudQualityProfileCreate,
-		Read:   resourceSonarcloudQualityProfileRead,
-		Delete: resourceSonarcloudQualityProfileDelete,
+		CreateContext: resourceSonarcloudQualityProfileCreate,
+		ReadContext:   resourceSonarcloudQualityProfileRead,
+		DeleteContext: resourceSonarcloudQualityProfileDelete,
 		Importer: &schema.ResourceImporter{
-			State: resourceSonarcloudQualityProfileImport,
+			StateContext: resourceSonarcloudQualityProfileImport,
+		},
+		CustomizeDiff: requireOrganization,
+		// Version 0 stored every quality profile with the ID 0
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceSonarcloudQualityProfileV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceSonarcloudQualityProfileStateUpgradeV0,
+			},
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultTimeout),
+			Read:   schema.DefaultTimeout(defaultTimeout),
+			Delete: schema.DefaultTimeout(defaultTimeout),
 		},
 
 		// Define the fields of this schema.
@@ -27,118 +41,146 @@ func resourceSonarcloudQualityProfile() *schema.Resource {
 				Required: true,
 				ForceNew: true,
 			},
-                        "language": {
-                                Type:     schema.TypeString,
-                                Required: true,
-                                ForceNew: true,
-                        },
-                        "organization": {     
-                                Type:     schema.TypeString,
-                                Required: true,
-                                ForceNew: true,
-                        },
-
+			"language": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"organization": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
 		},
 	}
 }
 
-func resourceSonarcloudQualityProfileCreate(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/qualityprofiles/create"
-	sonarCloudURL.RawQuery = url.Values{
-		"name": []string{d.Get("name").(string)},
-                "language": []string{d.Get("language").(string)},
-                "organization": []string{d.Get("organization").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceQualityProfileCreate",
-	)
+func resourceSonarcloudQualityProfileCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	qualityProfileResponse, err := m.(*ProviderConfiguration).client.QualityProfiles.Create(ctx, qualityProfileOptions(d, m))
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
-	defer resp.Body.Close()
 
-	// Decode response into struct
-	qualityProfileResponse := CreateQualityProfileResponse{}
-	err = json.NewDecoder(resp.Body).Decode(&qualityProfileResponse)
+	d.SetId(qualityProfileResponse.Profile.Key)
+	return resourceSonarcloudQualityProfileRead(ctx, d, m)
+}
+
+func resourceSonarcloudQualityProfileRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// An imported quality profile is only known by its key, search every
+	// quality profile of the organization then
+	qualityProfileReadResponse, err := m.(*ProviderConfiguration).client.QualityProfiles.Search(ctx, qualityProfileOptions(d, m))
+	if isNotFound(err) {
+		// Quality profile not found
+		d.SetId("")
+		return nil
+	}
 	if err != nil {
-		log.WithError(err).Error("resourceQualityProfileCreate: Failed to decode json into struct")
+		return diag.FromErr(err)
+	}
+
+	// Loop over all quality profiles to see if the profile we need exists.
+	readSuccess := false
+	for _, value := range qualityProfileReadResponse.Profiles {
+		if d.Id() == value.Key {
+			d.SetId(value.Key)
+			d.Set("name", value.Name)
+			d.Set("language", value.Language)
+			// SonarQube has no organizations
+			if value.Organization != "" {
+				d.Set("organization", value.Organization)
+			}
+			readSuccess = true
+		}
+	}
+
+	if !readSuccess {
+		// Quality profile not found
+		d.SetId("")
 	}
 
-	d.SetId(strconv.FormatInt(qualityProfileResponse.ID, 10))
 	return nil
 }
 
-func resourceSonarcloudQualityProfileRead(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/qualityprofiles/search"
-	sonarCloudURL.RawQuery = url.Values{
-		"qualityProfile": []string{d.Get("name").(string)},
-                "organization": []string{d.Get("organization").(string)},
-                "language": []string{d.Get("language").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"GET",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceQualityProfileRead",
-	)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+func resourceSonarcloudQualityProfileDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	return diag.FromErr(m.(*ProviderConfiguration).client.QualityProfiles.Delete(ctx, qualityProfileOptions(d, m)))
+}
 
-	// Decode response into struct
-	qualityProfileReadResponse := GetQualityProfile{}
-	err = json.NewDecoder(resp.Body).Decode(&qualityProfileReadResponse)
-	if err != nil {
-		log.WithError(err).Error("resourceQualityProfileRead: Failed to decode json into struct")
+func resourceSonarcloudQualityProfileImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	key := d.Id()
+	if diags := resourceSonarcloudQualityProfileRead(ctx, d, m); diags.HasError() {
+		return nil, diagnosticsError(diags)
 	}
+	if d.Id() == "" {
+		return nil, fmt.Errorf("Quality profile %q not found", key)
+	}
+	return []*schema.ResourceData{d}, nil
+}
 
-	return nil
+// resourceSonarcloudQualityProfileV0 is the schema of version 0, which
+// identified quality profiles by the ID in the create response. That
+// response has no ID, so every quality profile was stored with the ID 0.
+func resourceSonarcloudQualityProfileV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"language": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"organization": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+		},
+	}
 }
 
-func resourceSonarcloudQualityProfileDelete(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/qualityprofiles/delete"
-	sonarCloudURL.RawQuery = url.Values{
-		"qualityProfile": []string{d.Get("name").(string)},
-                "organization": []string{d.Get("organization").(string)},
-                "language": []string{d.Get("language").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusNoContent,
-		"resourceQualityProfileDelete",
-	)
-	if err != nil {
-		return err
+// resourceSonarcloudQualityProfileStateUpgradeV0 replaces the ID 0 with the
+// key of the quality profile with the name and language of the resource.
+func resourceSonarcloudQualityProfileStateUpgradeV0(ctx context.Context, rawState map[string]interface{}, m interface{}) (map[string]interface{}, error) {
+	name, _ := rawState["name"].(string)
+	language, _ := rawState["language"].(string)
+	organization, _ := rawState["organization"].(string)
+	if organization == "" {
+		organization = m.(*ProviderConfiguration).organization
+	}
+	if m.(*ProviderConfiguration).platform == platformSonarQube {
+		organization = ""
 	}
-	defer resp.Body.Close()
 
-	// Decode response into struct
-	qualityProfileReadResponse := GetQualityProfile{}
-	err = json.NewDecoder(resp.Body).Decode(&qualityProfileReadResponse)
-	if err != nil {
-		log.WithError(err).Error("resourceQualityProfileDelete: Failed to decode json into struct")
+	qualityProfileReadResponse, err := m.(*ProviderConfiguration).client.QualityProfiles.Search(ctx, client.QualityProfileOptions{
+		Name:         name,
+		Language:     language,
+		Organization: organization,
+	})
+	if err != nil && !isNotFound(err) {
+		return nil, fmt.Errorf("Looking up the key of quality profile %q: %+v", name, err)
 	}
 
-	return nil
+	// A quality profile that no longer exists keeps the ID 0, and is
+	// removed from the state when it is read
+	if qualityProfileReadResponse != nil {
+		for _, value := range qualityProfileReadResponse.Profiles {
+			if value.Name == name && value.Language == language {
+				rawState["id"] = value.Key
+			}
+		}
+	}
+
+	return rawState, nil
 }
 
-func resourceSonarcloudQualityProfileImport(d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
-	if err := resourceSonarcloudQualityProfileRead(d, m); err != nil {
-		return nil, err
+// qualityProfileOptions identifies the quality profile of the resource.
+func qualityProfileOptions(d *schema.ResourceData, m interface{}) client.QualityProfileOptions {
+	return client.QualityProfileOptions{
+		Name:         d.Get("name").(string),
+		Language:     d.Get("language").(string),
+		Organization: getOrganization(d, m),
 	}
-	return []*schema.ResourceData{d}, nil
 }
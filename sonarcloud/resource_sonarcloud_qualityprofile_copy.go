@@ -1,142 +1,71 @@
 package sonarcloud
 
 import (
-	"encoding/json"
-	"net/http"
-	"net/url"
+	"context"
 
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
-	log "github.com/sirupsen/logrus"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 // Returns the resource represented by this file.
 func resourceSonarcloudQualityProfileCopy() *schema.Resource {
 	return &schema.Resource{
-		// Create: resourceSonarcloudQualityProfileCreate,
-		Read:   resourceSonarcloudQualityProfileCopyGet,
-		Delete: resourceSonarcloudQualityProfileCopyDelete,
-		// Importer: &schema.ResourceImporter{
-		// 	State: resourceSonarcloudQualityProfileImport,
-		// },
+		CreateContext: resourceSonarcloudQualityProfileCopyCreate,
+		ReadContext:   resourceSonarcloudQualityProfileRead,
+		DeleteContext: resourceSonarcloudQualityProfileDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceSonarcloudQualityProfileImport,
+		},
+		CustomizeDiff: requireOrganization,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultTimeout),
+			Read:   schema.DefaultTimeout(defaultTimeout),
+			Delete: schema.DefaultTimeout(defaultTimeout),
+		},
 
 		// Define the fields of this schema.
 		Schema: map[string]*schema.Schema{
-			"name": {
+			"from_key": {
 				Type:     schema.TypeString,
 				Required: true,
 				ForceNew: true,
+				// The rules are only copied when the quality profile is
+				// created, an imported copy does not know its source
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return d.Id() != ""
+				},
 			},
-			"language": {
+			"name": {
 				Type:     schema.TypeString,
 				Required: true,
 				ForceNew: true,
 			},
+			// The copy has the language and organization of the
+			// copied quality profile
+			"language": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 			"organization": {
 				Type:     schema.TypeString,
-				Required: true,
+				Optional: true,
+				Computed: true,
 				ForceNew: true,
 			},
 		},
 	}
 }
 
-// func resourceSonarcloudQualityProfileCreate(d *schema.ResourceData, m interface{}) error {
-// 	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-// 	sonarCloudURL.Path = "api/qualityprofiles/create"
-// 	sonarCloudURL.RawQuery = url.Values{
-// 		"name": []string{d.Get("name").(string)},
-//                 "language": []string{d.Get("language").(string)},
-//                 "organization": []string{d.Get("organization").(string)},
-// 	}.Encode()
-
-// 	resp, err := httpRequestHelper(
-// 		m.(*ProviderConfiguration).httpClient,
-// 		"POST",
-// 		sonarCloudURL.String(),
-// 		http.StatusOK,
-// 		"resourceQualityProfileCreate",
-// 	)
-// 	if err != nil {
-// 		return err
-// 	}
-// 	defer resp.Body.Close()
-
-// 	// Decode response into struct
-// 	qualityProfileResponse := CreateQualityProfileResponse{}
-// 	err = json.NewDecoder(resp.Body).Decode(&qualityProfileResponse)
-// 	if err != nil {
-// 		log.WithError(err).Error("resourceQualityProfileCreate: Failed to decode json into struct")
-// 	}
-
-// 	d.SetId(strconv.FormatInt(qualityProfileResponse.ID, 10))
-// 	return nil
-// }
-
-func resourceSonarcloudQualityProfileCopyGet(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	//sonarCloudURL.Path = "api/qualityprofiles/search"
-	sonarCloudURL.Path = "api/qualityprofiles/copy"
-	sonarCloudURL.RawQuery = url.Values{
-		"fromKey": []string{d.Get("fromkey").(string)},
-		"toKey":   []string{d.Get("tokey").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"GET",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceQualityProfileRead",
-	)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	// Decode response into struct
-	qualityProfileReadResponse := GetQualityProfile{}
-	err = json.NewDecoder(resp.Body).Decode(&qualityProfileReadResponse)
-	if err != nil {
-		log.WithError(err).Error("resourceQualityProfileRead: Failed to decode json into struct")
-	}
-
-	return nil
-}
-
-func resourceSonarcloudQualityProfileCopyDelete(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/qualityprofiles/delete"
-	sonarCloudURL.RawQuery = url.Values{
-		"qualityProfile": []string{d.Get("name").(string)},
-		"organization":   []string{d.Get("organization").(string)},
-		"language":       []string{d.Get("language").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusNoContent,
-		"resourceQualityProfileDelete",
+func resourceSonarcloudQualityProfileCopyCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	qualityProfileResponse, err := m.(*ProviderConfiguration).client.QualityProfiles.Copy(ctx,
+		d.Get("from_key").(string),
+		d.Get("name").(string),
 	)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
-	defer resp.Body.Close()
 
-	// Decode response into struct
-	qualityProfileReadResponse := GetQualityProfile{}
-	err = json.NewDecoder(resp.Body).Decode(&qualityProfileReadResponse)
-	if err != nil {
-		log.WithError(err).Error("resourceQualityProfileDelete: Failed to decode json into struct")
-	}
-
-	return nil
+	d.SetId(qualityProfileResponse.Key)
+	d.Set("language", qualityProfileResponse.Language)
+	return resourceSonarcloudQualityProfileRead(ctx, d, m)
 }
-
-// func resourceSonarcloudQualityProfileImport(d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
-// 	if err := resourceSonarcloudQualityProfileRead(d, m); err != nil {
-// 		return nil, err
-// 	}
-// 	return []*schema.ResourceData{d}, nil
-// }
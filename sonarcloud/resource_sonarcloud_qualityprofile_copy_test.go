@@ -0,0 +1,58 @@
+package sonarcloud
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/meetdpv/SonarCloud/tests/sonarcloudtest"
+)
+
+func testAccSonarcloudQualityProfileCopyConfig(server *sonarcloudtest.Server, copy string) string {
+	return server.ProviderConfig() + `
+resource "sonarcloud_qualityprofile" "test" {
+  name     = "Strict"
+  language = "java"
+}
+` + copy
+}
+
+func TestAccSonarcloudQualityProfileCopy(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_qualityprofile_copy"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSonarcloudQualityProfileCopyConfig(server, `
+resource "sonarcloud_qualityprofile_copy" "test" {
+  from_key = sonarcloud_qualityprofile.test.id
+  name     = "Strict copy"
+}
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("sonarcloud_qualityprofile_copy.test", "id"),
+					resource.TestCheckResourceAttr("sonarcloud_qualityprofile_copy.test", "name", "Strict copy"),
+					resource.TestCheckResourceAttr("sonarcloud_qualityprofile_copy.test", "language", "java"),
+				),
+			},
+			{
+				// A copy can not be changed, another name replaces it
+				Config: testAccSonarcloudQualityProfileCopyConfig(server, `
+resource "sonarcloud_qualityprofile_copy" "test" {
+  from_key = sonarcloud_qualityprofile.test.id
+  name     = "Very strict"
+}
+`),
+				Check: resource.TestCheckResourceAttr("sonarcloud_qualityprofile_copy.test", "name", "Very strict"),
+			},
+			{
+				ResourceName:      "sonarcloud_qualityprofile_copy.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+				// Only the rules are copied, the source is not known
+				ImportStateVerifyIgnore: []string{"from_key"},
+			},
+		},
+	})
+}
@@ -0,0 +1,108 @@
+package sonarcloud
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/meetdpv/SonarCloud/sonarcloud/client"
+	"github.com/meetdpv/SonarCloud/tests/sonarcloudtest"
+)
+
+func TestAccSonarcloudQualityProfile(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_qualityprofile"),
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_qualityprofile" "test" {
+  name     = "Strict"
+  language = "java"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestMatchResourceAttr("sonarcloud_qualityprofile.test", "id", regexp.MustCompile(`^AX`)),
+					resource.TestCheckResourceAttr("sonarcloud_qualityprofile.test", "name", "Strict"),
+					resource.TestCheckResourceAttr("sonarcloud_qualityprofile.test", "language", "java"),
+				),
+			},
+			{
+				// A quality profile can not be changed, another language
+				// replaces it
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_qualityprofile" "test" {
+  name     = "Strict"
+  language = "js"
+}
+`,
+				Check: resource.TestCheckResourceAttr("sonarcloud_qualityprofile.test", "language", "js"),
+			},
+			{
+				ResourceName:      "sonarcloud_qualityprofile.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:  "sonarcloud_qualityprofile.test",
+				ImportState:   true,
+				ImportStateId: "unknown",
+				ExpectError:   regexp.MustCompile(`Quality profile "unknown" not found`),
+			},
+		},
+	})
+}
+
+func TestResourceSonarcloudQualityProfileStateUpgradeV0(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+	provider := Provider()
+	diags := provider.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{
+		"scheme":   server.Scheme(),
+		"host":     server.Host(),
+		"token":    "sonarcloudtest",
+		"platform": server.Platform(),
+	}))
+	if diags.HasError() {
+		t.Fatalf("configuring the provider: %v", diags)
+	}
+
+	m := provider.Meta().(*ProviderConfiguration)
+	qualityProfileResponse, err := m.client.QualityProfiles.Create(context.Background(), client.QualityProfileOptions{
+		Name:     "Strict",
+		Language: "java",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		language string
+		want     string
+	}{
+		{name: "Strict", language: "java", want: qualityProfileResponse.Profile.Key},
+		{name: "Strict", language: "js", want: "0"},
+		{name: "Unknown", language: "java", want: "0"},
+	}
+	for _, test := range tests {
+		t.Run(test.name+"/"+test.language, func(t *testing.T) {
+			rawState := map[string]interface{}{
+				"id":       "0",
+				"name":     test.name,
+				"language": test.language,
+			}
+
+			upgraded, err := resourceSonarcloudQualityProfileStateUpgradeV0(context.Background(), rawState, m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if upgraded["id"] != test.want {
+				t.Errorf("expected the ID %q, got %q", test.want, upgraded["id"])
+			}
+		})
+	}
+}
@@ -1,24 +1,29 @@
 package sonarcloud
 
 import (
-	"encoding/json"
-	"fmt"
-	"net/http"
-	"net/url"
-	"strconv"
+	"context"
 
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/meetdpv/SonarCloud/sonarcloud/client"
 )
 
 // Returns the resource represented by this file.
 func resourceSonarcloudUser() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceSonarcloudUserCreate,
-		Read:   resourceSonarcloudUserRead,
-		Update: resourceSonarcloudUserUpdate,
-		Delete: resourceSonarcloudUserDelete,
+		CreateContext: resourceSonarcloudUserCreate,
+		ReadContext:   resourceSonarcloudUserRead,
+		UpdateContext: resourceSonarcloudUserUpdate,
+		DeleteContext: resourceSonarcloudUserDelete,
 		Importer: &schema.ResourceImporter{
-			State: resourceSonarcloudUserImport,
+			StateContext: resourceSonarcloudUserImport,
+		},
+		CustomizeDiff: requireSonarQube("sonarcloud_user"),
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultTimeout),
+			Read:   schema.DefaultTimeout(defaultTimeout),
+			Update: schema.DefaultTimeout(defaultTimeout),
+			Delete: schema.DefaultTimeout(defaultTimeout),
 		},
 
 		// Define the fields of this schema.
@@ -52,79 +57,31 @@ func resourceSonarcloudUser() *schema.Resource {
 	}
 }
 
-func resourceSonarcloudUserCreate(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/users/create"
-	isLocal := d.Get("is_local").(bool)
-
-	rawQuery := url.Values{
-		"login": []string{d.Get("login_name").(string)},
-		"name":  []string{d.Get("name").(string)},
-		"local": []string{strconv.FormatBool(isLocal)},
-	}
-
-	if password, ok := d.GetOk("password"); ok {
-		rawQuery.Add("password", password.(string))
-	}
-
-	if email, ok := d.GetOk("email"); ok {
-		rawQuery.Add("email", email.(string))
-	}
-
-	sonarCloudURL.RawQuery = rawQuery.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceSonarcloudUserCreate",
-	)
-	if err != nil {
-		return fmt.Errorf("Error creating Sonarcloud user: %+v", err)
-	}
-	defer resp.Body.Close()
-
-	// Decode response into struct
-	userResponse := CreateUserResponse{}
-	err = json.NewDecoder(resp.Body).Decode(&userResponse)
+func resourceSonarcloudUserCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	userResponse, err := m.(*ProviderConfiguration).client.Users.Create(ctx, client.CreateUserOptions{
+		Login:    d.Get("login_name").(string),
+		Name:     d.Get("name").(string),
+		Email:    d.Get("email").(string),
+		Password: d.Get("password").(string),
+		Local:    d.Get("is_local").(bool),
+	})
 	if err != nil {
-		return fmt.Errorf("resourceSonarcloudUserCreate: Failed to decode json into struct: %+v", err)
+		return diag.Errorf("Error creating Sonarcloud user: %+v", err)
 	}
 
 	if userResponse.User.Login != "" {
 		d.SetId(userResponse.User.Login)
 	} else {
-		return fmt.Errorf("resourceSonarcloudUserCreate: Create response didn't contain the user login")
+		return diag.Errorf("resourceSonarcloudUserCreate: Create response didn't contain the user login")
 	}
 
-	return resourceSonarcloudUserRead(d, m)
+	return resourceSonarcloudUserRead(ctx, d, m)
 }
 
-func resourceSonarcloudUserRead(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/users/search"
-	sonarCloudURL.RawQuery = url.Values{
-		"q": []string{d.Get("login_name").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"GET",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceSonarcloudUserRead",
-	)
+func resourceSonarcloudUserRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	userResponse, err := m.(*ProviderConfiguration).client.Users.Search(ctx, d.Get("login_name").(string))
 	if err != nil {
-		return fmt.Errorf("Error reading Sonarcloud user: %+v", err)
-	}
-	defer resp.Body.Close()
-
-	// Decode response into struct
-	userResponse := GetUser{}
-	err = json.NewDecoder(resp.Body).Decode(&userResponse)
-	if err != nil {
-		return fmt.Errorf("resourceSonarcloudUserCreate: Failed to decode json into struct: %+v", err)
+		return diag.Errorf("Error reading Sonarcloud user: %+v", err)
 	}
 
 	// Loop over all users to see if the current user exists.
@@ -148,81 +105,40 @@ func resourceSonarcloudUserRead(d *schema.ResourceData, m interface{}) error {
 	return nil
 }
 
-func resourceSonarcloudUserUpdate(d *schema.ResourceData, m interface{}) error {
+func resourceSonarcloudUserUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	sonarcloudClient := m.(*ProviderConfiguration).client
 
 	// handle default updates (api/users/update)
 	if d.HasChange("email") {
-		sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-		sonarCloudURL.Path = "api/users/update"
-		sonarCloudURL.RawQuery = url.Values{
-			"login": []string{d.Id()},
-			"email": []string{d.Get("email").(string)},
-		}.Encode()
-
-		resp, err := httpRequestHelper(
-			m.(*ProviderConfiguration).httpClient,
-			"POST",
-			sonarCloudURL.String(),
-			http.StatusOK,
-			"resourceSonarcloudUserUpdate",
-		)
+		err := sonarcloudClient.Users.Update(ctx, d.Id(), d.Get("email").(string))
 		if err != nil {
-			return fmt.Errorf("Error updating Sonarcloud user: %+v", err)
+			return diag.Errorf("Error updating Sonarcloud user: %+v", err)
 		}
-		defer resp.Body.Close()
 	}
 
 	// handle password updates (api/users/change_password)
 	if d.HasChange("password") {
-
-		sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-		sonarCloudURL.Path = "api/users/change_password"
-		sonarCloudURL.RawQuery = url.Values{
-			"login":    []string{d.Id()},
-			"password": []string{d.Get("password").(string)},
-		}.Encode()
-
-		resp, err := httpRequestHelper(
-			m.(*ProviderConfiguration).httpClient,
-			"POST",
-			sonarCloudURL.String(),
-			http.StatusNoContent,
-			"resourceSonarcloudUserUpdate",
-		)
+		err := sonarcloudClient.Users.ChangePassword(ctx, d.Id(), d.Get("password").(string))
 		if err != nil {
-			return fmt.Errorf("Error updating Sonarcloud user: %+v", err)
+			return diag.Errorf("Error updating Sonarcloud user: %+v", err)
 		}
-		defer resp.Body.Close()
 	}
 
-	return resourceSonarcloudUserRead(d, m)
+	return resourceSonarcloudUserRead(ctx, d, m)
 }
 
-func resourceSonarcloudUserDelete(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/users/deactivate"
-	sonarCloudURL.RawQuery = url.Values{
-		"login": []string{d.Id()},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceSonarcloudUserDelete",
-	)
+func resourceSonarcloudUserDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	err := m.(*ProviderConfiguration).client.Users.Deactivate(ctx, d.Id())
 	if err != nil {
-		return fmt.Errorf("Error deleting (deactivating) Sonarcloud user: %+v", err)
+		return diag.Errorf("Error deleting (deactivating) Sonarcloud user: %+v", err)
 	}
-	defer resp.Body.Close()
 
 	return nil
 }
 
-func resourceSonarcloudUserImport(d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
-	if err := resourceSonarcloudUserRead(d, m); err != nil {
-		return nil, err
+func resourceSonarcloudUserImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	if diags := resourceSonarcloudUserRead(ctx, d, m); diags.HasError() {
+		return nil, diagnosticsError(diags)
 	}
 	return []*schema.ResourceData{d}, nil
 }
@@ -0,0 +1,73 @@
+package sonarcloud
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/meetdpv/SonarCloud/tests/sonarcloudtest"
+)
+
+func TestAccSonarcloudUser(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_user"),
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_user" "test" {
+  login_name = "jdoe"
+  name       = "John Doe"
+  email      = "jdoe@example.com"
+  password   = "secret-1"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_user.test", "id", "jdoe"),
+					resource.TestCheckResourceAttr("sonarcloud_user.test", "name", "John Doe"),
+					resource.TestCheckResourceAttr("sonarcloud_user.test", "email", "jdoe@example.com"),
+					resource.TestCheckResourceAttr("sonarcloud_user.test", "is_local", "true"),
+				),
+			},
+			{
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_user" "test" {
+  login_name = "jdoe"
+  name       = "John Doe"
+  email      = "john.doe@example.com"
+  password   = "secret-2"
+}
+`,
+				Check: resource.TestCheckResourceAttr("sonarcloud_user.test", "email", "john.doe@example.com"),
+			},
+			{
+				ResourceName:      "sonarcloud_user.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+				// The API never returns the password
+				ImportStateVerifyIgnore: []string{"password"},
+			},
+		},
+	})
+}
+
+func TestAccSonarcloudUserSonarCloud(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{Platform: sonarcloudtest.PlatformSonarCloud})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: server.ProviderConfig() + `
+resource "sonarcloud_user" "test" {
+  login_name = "jdoe"
+  name       = "John Doe"
+}
+`,
+				ExpectError: regexp.MustCompile(`sonarcloud_user is only supported on SonarQube`),
+			},
+		},
+	})
+}
@@ -1,20 +1,24 @@
 package sonarcloud
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
-	"net/url"
 
-	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 // Returns the resource represented by this file.
 func resourceSonarcloudUserToken() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceSonarcloudUserTokenCreate,
-		Read:   resourceSonarcloudUserTokenRead,
-		Delete: resourceSonarcloudUserTokenDelete,
+		CreateContext: resourceSonarcloudUserTokenCreate,
+		ReadContext:   resourceSonarcloudUserTokenRead,
+		DeleteContext: resourceSonarcloudUserTokenDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultTimeout),
+			Read:   schema.DefaultTimeout(defaultTimeout),
+			Delete: schema.DefaultTimeout(defaultTimeout),
+		},
 
 		// Define the fields of this schema.
 		Schema: map[string]*schema.Schema{
@@ -28,6 +32,11 @@ func resourceSonarcloudUserToken() *schema.Resource {
 				Required: true,
 				ForceNew: true,
 			},
+			"expiration_date": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
 			"token": {
 				Type:      schema.TypeString,
 				Computed:  true,
@@ -37,34 +46,21 @@ func resourceSonarcloudUserToken() *schema.Resource {
 	}
 }
 
-func resourceSonarcloudUserTokenCreate(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/user_tokens/generate"
-
-	rawQuery := url.Values{
-		"login": []string{d.Get("login_name").(string)},
-		"name":  []string{d.Get("name").(string)},
+func resourceSonarcloudUserTokenCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	expirationDate := d.Get("expiration_date").(string)
+	if expirationDate != "" {
+		if err := m.(*ProviderConfiguration).requireCapability(capabilityTokenExpiration); err != nil {
+			return attributeError("expiration_date", err)
+		}
 	}
 
-	sonarCloudURL.RawQuery = rawQuery.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceSonarcloudUserTokenCreate",
+	tokenResponse, err := m.(*ProviderConfiguration).client.UserTokens.Generate(ctx,
+		d.Get("login_name").(string),
+		d.Get("name").(string),
+		expirationDate,
 	)
 	if err != nil {
-		return fmt.Errorf("Error creating Sonarcloud user token: %+v", err)
-	}
-	defer resp.Body.Close()
-
-	// Decode response into struct
-	tokenResponse := Token{}
-	err = json.NewDecoder(resp.Body).Decode(&tokenResponse)
-	if err != nil {
-		return fmt.Errorf("resourceSonarcloudUserTokenCreate: Failed to decode json into struct: %+v", err)
+		return diag.Errorf("Error creating Sonarcloud user token: %+v", err)
 	}
 
 	if tokenResponse.Login != "" {
@@ -74,39 +70,24 @@ func resourceSonarcloudUserTokenCreate(d *schema.ResourceData, m interface{}) er
 		if tokenResponse.Token != "" {
 			d.Set("token", tokenResponse.Token)
 		} else {
-			return fmt.Errorf("resourceSonarcloudUserTokenCreate: Create response didn't contain the token")
+			return diag.Errorf("resourceSonarcloudUserTokenCreate: Create response didn't contain the token")
 		}
 	} else {
-		return fmt.Errorf("resourceSonarcloudUserTokenCreate: Create response didn't contain the user login")
+		return diag.Errorf("resourceSonarcloudUserTokenCreate: Create response didn't contain the user login")
 	}
 
-	return resourceSonarcloudUserTokenRead(d, m)
+	return resourceSonarcloudUserTokenRead(ctx, d, m)
 }
 
-func resourceSonarcloudUserTokenRead(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/user_tokens/search"
-	sonarCloudURL.RawQuery = url.Values{
-		"login": []string{d.Get("login_name").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"GET",
-		sonarCloudURL.String(),
-		http.StatusOK,
-		"resourceSonarcloudUserTokenRead",
-	)
-	if err != nil {
-		return fmt.Errorf("Error reading Sonarcloud user tokens: %+v", err)
+func resourceSonarcloudUserTokenRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	getTokensResponse, err := m.(*ProviderConfiguration).client.UserTokens.Search(ctx, d.Get("login_name").(string))
+	if isNotFound(err) {
+		// User not found
+		d.SetId("")
+		return nil
 	}
-	defer resp.Body.Close()
-
-	// Decode response into struct
-	getTokensResponse := GetTokens{}
-	err = json.NewDecoder(resp.Body).Decode(&getTokensResponse)
 	if err != nil {
-		return fmt.Errorf("resourceSonarcloudUserTokenCreate: Failed to decode json into struct: %+v", err)
+		return diag.Errorf("Error reading Sonarcloud user tokens: %+v", err)
 	}
 
 	// Loop over all user token to see if the current token exists.
@@ -117,6 +98,7 @@ func resourceSonarcloudUserTokenRead(d *schema.ResourceData, m interface{}) erro
 				d.SetId(fmt.Sprintf("%s/%s", d.Get("login_name").(string), d.Get("name").(string)))
 				d.Set("login_name", getTokensResponse.Login)
 				d.Set("name", value.Name)
+				d.Set("expiration_date", trimExpirationDate(value.ExpirationDate))
 				readSuccess = true
 			}
 		}
@@ -133,25 +115,23 @@ func resourceSonarcloudUserTokenRead(d *schema.ResourceData, m interface{}) erro
 	return nil
 }
 
-func resourceSonarcloudUserTokenDelete(d *schema.ResourceData, m interface{}) error {
-	sonarCloudURL := m.(*ProviderConfiguration).sonarCloudURL
-	sonarCloudURL.Path = "api/user_tokens/revoke"
-	sonarCloudURL.RawQuery = url.Values{
-		"login": []string{d.Get("login_name").(string)},
-		"name":  []string{d.Get("name").(string)},
-	}.Encode()
-
-	resp, err := httpRequestHelper(
-		m.(*ProviderConfiguration).httpClient,
-		"POST",
-		sonarCloudURL.String(),
-		http.StatusNoContent,
-		"resourceSonarcloudUserTokenDelete",
+func resourceSonarcloudUserTokenDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	err := m.(*ProviderConfiguration).client.UserTokens.Revoke(ctx,
+		d.Get("login_name").(string),
+		d.Get("name").(string),
 	)
 	if err != nil {
-		return fmt.Errorf("Error deleting Sonarcloud user token: %+v", err)
+		return diag.Errorf("Error deleting Sonarcloud user token: %+v", err)
 	}
-	defer resp.Body.Close()
 
 	return nil
 }
+
+// trimExpirationDate returns the date part of the expiration timestamp the API
+// returns, e.g. "2021-03-01" for "2021-03-01T00:00:00+0000".
+func trimExpirationDate(expirationDate string) string {
+	if len(expirationDate) > len("2006-01-02") {
+		return expirationDate[:len("2006-01-02")]
+	}
+	return expirationDate
+}
@@ -0,0 +1,102 @@
+package sonarcloud
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/meetdpv/SonarCloud/tests/sonarcloudtest"
+)
+
+func testAccSonarcloudUserTokenConfig(server *sonarcloudtest.Server, token string) string {
+	return server.ProviderConfig() + `
+resource "sonarcloud_user" "test" {
+  login_name = "jdoe"
+  name       = "John Doe"
+  password   = "secret"
+}
+` + token
+}
+
+func TestAccSonarcloudUserToken(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_user_token"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSonarcloudUserTokenConfig(server, `
+resource "sonarcloud_user_token" "test" {
+  login_name = sonarcloud_user.test.login_name
+  name       = "ci"
+}
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_user_token.test", "id", "jdoe/ci"),
+					resource.TestMatchResourceAttr("sonarcloud_user_token.test", "token", regexp.MustCompile(`^squ_`)),
+				),
+			},
+			{
+				// Tokens can not be changed, a new name replaces the token
+				Config: testAccSonarcloudUserTokenConfig(server, `
+resource "sonarcloud_user_token" "test" {
+  login_name = sonarcloud_user.test.login_name
+  name       = "release"
+}
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("sonarcloud_user_token.test", "id", "jdoe/release"),
+					resource.TestMatchResourceAttr("sonarcloud_user_token.test", "token", regexp.MustCompile(`^squ_`)),
+				),
+			},
+			{
+				ResourceName:  "sonarcloud_user_token.test",
+				ImportState:   true,
+				ImportStateId: "jdoe/release",
+				ExpectError:   regexp.MustCompile(`doesn't support import`),
+			},
+		},
+	})
+}
+
+func TestAccSonarcloudUserTokenExpirationDate(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{Version: "9.6.0.59041"})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		CheckDestroy:      testAccCheckDestroy(server, "sonarcloud_user_token"),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSonarcloudUserTokenConfig(server, `
+resource "sonarcloud_user_token" "test" {
+  login_name      = sonarcloud_user.test.login_name
+  name            = "ci"
+  expiration_date = "2099-01-31"
+}
+`),
+				Check: resource.TestCheckResourceAttr("sonarcloud_user_token.test", "expiration_date", "2099-01-31"),
+			},
+		},
+	})
+}
+
+func TestAccSonarcloudUserTokenExpirationDateUnsupported(t *testing.T) {
+	server := testAccServer(t, sonarcloudtest.Options{})
+
+	resource.Test(t, resource.TestCase{
+		ProviderFactories: testAccProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSonarcloudUserTokenConfig(server, `
+resource "sonarcloud_user_token" "test" {
+  login_name      = sonarcloud_user.test.login_name
+  name            = "ci"
+  expiration_date = "2099-01-31"
+}
+`),
+				ExpectError: regexp.MustCompile(`User token expiration`),
+			},
+		},
+	})
+}
@@ -0,0 +1,420 @@
+package sonarcloudtest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// permissionScope is where permissions are granted: the organization (or the
+// whole server on SonarQube), a project or a permission template.
+type permissionScope struct {
+	Organization string
+	ProjectKey   string
+	TemplateID   string
+}
+
+// principal is either a user or a group.
+type principal struct {
+	Login     string
+	GroupName string
+}
+
+var (
+	globalPermissions  = []string{"admin", "gateadmin", "profileadmin", "provisioning", "scan"}
+	projectPermissions = []string{"admin", "codeviewer", "issueadmin", "securityhotspotadmin", "scan", "user"}
+)
+
+type permissionTemplate struct {
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	Description       string `json:"description,omitempty"`
+	ProjectKeyPattern string `json:"projectKeyPattern,omitempty"`
+	CreatedAt         string `json:"createdAt"`
+	UpdatedAt         string `json:"updatedAt"`
+	Organization      string `json:"-"`
+	IsDefault         bool   `json:"-"`
+}
+
+type permissionTemplateResponse struct {
+	PermissionTemplate *permissionTemplate `json:"permissionTemplate"`
+}
+
+type defaultTemplate struct {
+	TemplateID string `json:"templateId"`
+	Qualifier  string `json:"qualifier"`
+}
+
+// searchTemplatesResponse is the only search response without paging
+type searchTemplatesResponse struct {
+	PermissionTemplates []*permissionTemplate `json:"permissionTemplates"`
+	DefaultTemplates    []defaultTemplate     `json:"defaultTemplates"`
+}
+
+type userPermissions struct {
+	Login       string   `json:"login"`
+	Name        string   `json:"name"`
+	Email       string   `json:"email,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+type searchUserPermissionsResponse struct {
+	Paging paging             `json:"paging"`
+	Users  []*userPermissions `json:"users"`
+}
+
+type groupPermissions struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+type searchGroupPermissionsResponse struct {
+	Paging paging              `json:"paging"`
+	Groups []*groupPermissions `json:"groups"`
+}
+
+// permissionScope returns the scope of a permission request. A 404 response
+// is written when the project or template does not exist.
+func (s *Server) permissionScope(w http.ResponseWriter, params url.Values) (permissionScope, bool) {
+	organization, ok := s.organization(w, params)
+	if !ok {
+		return permissionScope{}, false
+	}
+	scope := permissionScope{
+		Organization: organization,
+		ProjectKey:   params.Get("projectKey"),
+		TemplateID:   params.Get("templateId"),
+	}
+
+	if scope.ProjectKey != "" {
+		if p, ok := s.projects[scope.ProjectKey]; !ok || p.Organization != organization {
+			writeError(w, http.StatusNotFound, "Project key '%s' not found", scope.ProjectKey)
+			return permissionScope{}, false
+		}
+	}
+	if scope.TemplateID != "" {
+		if t, ok := s.templates[scope.TemplateID]; !ok || t.Organization != organization {
+			writeError(w, http.StatusNotFound, "Permission template with id '%s' is not found", scope.TemplateID)
+			return permissionScope{}, false
+		}
+	}
+	return scope, true
+}
+
+// validPermission writes a 400 response unless the permission parameter can be
+// granted in scope.
+func validPermission(w http.ResponseWriter, params url.Values, scope permissionScope) bool {
+	if !required(w, params, "permission") {
+		return false
+	}
+
+	valid := globalPermissions
+	if scope.ProjectKey != "" || scope.TemplateID != "" {
+		valid = projectPermissions
+	}
+	if permission := params.Get("permission"); !contains(valid, permission) {
+		writeError(w, http.StatusBadRequest, "Value of parameter 'permission' (%s) must be one of: [%s]", permission, strings.Join(valid, ", "))
+		return false
+	}
+	return true
+}
+
+// permissionUser returns the principal of the login parameter. A 404 response
+// is written when the user does not exist.
+func (s *Server) permissionUser(w http.ResponseWriter, params url.Values) (principal, bool) {
+	if !required(w, params, "login") {
+		return principal{}, false
+	}
+
+	login := params.Get("login")
+	if u, ok := s.users[login]; !ok || !u.Active {
+		// SonarCloud users are managed outside of the organization
+		if s.options.Platform == PlatformSonarQube {
+			writeError(w, http.StatusNotFound, "User with login '%s' is not found", login)
+			return principal{}, false
+		}
+	}
+	return principal{Login: login}, true
+}
+
+// permissionGroup returns the principal of the groupName parameter. A 404
+// response is written when the group does not exist.
+func (s *Server) permissionGroup(w http.ResponseWriter, params url.Values, scope permissionScope) (principal, bool) {
+	if !required(w, params, "groupName") {
+		return principal{}, false
+	}
+
+	name := params.Get("groupName")
+	if s.findGroup(name, scope.Organization) == nil {
+		writeError(w, http.StatusNotFound, "No group with name '%s'", name)
+		return principal{}, false
+	}
+	return principal{GroupName: name}, true
+}
+
+// grant adds the permission to the principal in scope.
+func (s *Server) grant(scope permissionScope, p principal, permission string) {
+	if s.permissions[scope] == nil {
+		s.permissions[scope] = map[principal][]string{}
+	}
+	if !contains(s.permissions[scope][p], permission) {
+		s.permissions[scope][p] = append(s.permissions[scope][p], permission)
+		sort.Strings(s.permissions[scope][p])
+	}
+}
+
+// revoke removes the permission from the principal in scope.
+func (s *Server) revoke(scope permissionScope, p principal, permission string) {
+	var permissions []string
+	for _, existing := range s.permissions[scope][p] {
+		if existing != permission {
+			permissions = append(permissions, existing)
+		}
+	}
+	if len(permissions) == 0 {
+		delete(s.permissions[scope], p)
+		return
+	}
+	s.permissions[scope][p] = permissions
+}
+
+func (s *Server) addUserPermission(w http.ResponseWriter, params url.Values) {
+	scope, ok := s.permissionScope(w, params)
+	if !ok || !validPermission(w, params, scope) {
+		return
+	}
+	p, ok := s.permissionUser(w, params)
+	if !ok {
+		return
+	}
+
+	s.grant(scope, p, params.Get("permission"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) addGroupPermission(w http.ResponseWriter, params url.Values) {
+	scope, ok := s.permissionScope(w, params)
+	if !ok || !validPermission(w, params, scope) {
+		return
+	}
+	p, ok := s.permissionGroup(w, params, scope)
+	if !ok {
+		return
+	}
+
+	s.grant(scope, p, params.Get("permission"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) removeUserPermission(w http.ResponseWriter, params url.Values) {
+	scope, ok := s.permissionScope(w, params)
+	if !ok || !validPermission(w, params, scope) {
+		return
+	}
+	p, ok := s.permissionUser(w, params)
+	if !ok {
+		return
+	}
+
+	s.revoke(scope, p, params.Get("permission"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) removeGroupPermission(w http.ResponseWriter, params url.Values) {
+	scope, ok := s.permissionScope(w, params)
+	if !ok || !validPermission(w, params, scope) {
+		return
+	}
+	p, ok := s.permissionGroup(w, params, scope)
+	if !ok {
+		return
+	}
+
+	s.revoke(scope, p, params.Get("permission"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// searchUserPermissions lists the users with at least one permission in the
+// scope, which is what the real API returns without a search query.
+func (s *Server) searchUserPermissions(w http.ResponseWriter, params url.Values) {
+	scope, ok := s.permissionScope(w, params)
+	if !ok {
+		return
+	}
+
+	results := []*userPermissions{}
+	for p, permissions := range s.permissions[scope] {
+		if p.Login == "" {
+			continue
+		}
+		result := &userPermissions{Login: p.Login, Permissions: permissions}
+		if u, ok := s.users[p.Login]; ok {
+			result.Name = u.Name
+			result.Email = u.Email
+		}
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Login < results[j].Login })
+
+	start, end, paging, ok := page(w, params, len(results))
+	if !ok {
+		return
+	}
+	writeJSON(w, searchUserPermissionsResponse{Paging: paging, Users: results[start:end]})
+}
+
+// searchGroupPermissions lists the groups with at least one permission in the
+// scope.
+func (s *Server) searchGroupPermissions(w http.ResponseWriter, params url.Values) {
+	scope, ok := s.permissionScope(w, params)
+	if !ok {
+		return
+	}
+
+	results := []*groupPermissions{}
+	for p, permissions := range s.permissions[scope] {
+		if p.GroupName == "" {
+			continue
+		}
+		result := &groupPermissions{Name: p.GroupName, Permissions: permissions}
+		if g := s.findGroup(p.GroupName, scope.Organization); g != nil {
+			result.ID = g.ID
+			result.Description = g.Description
+		}
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	start, end, paging, ok := page(w, params, len(results))
+	if !ok {
+		return
+	}
+	writeJSON(w, searchGroupPermissionsResponse{Paging: paging, Groups: results[start:end]})
+}
+
+func (s *Server) createTemplate(w http.ResponseWriter, params url.Values) {
+	organization, ok := s.organization(w, params)
+	if !ok || !required(w, params, "name") {
+		return
+	}
+
+	name := params.Get("name")
+	if s.findTemplate(name, organization) != nil {
+		writeError(w, http.StatusBadRequest, "A template with the name '%s' already exists (case insensitive).", name)
+		return
+	}
+
+	now := time.Now().Format(timeFormat)
+	template := &permissionTemplate{
+		ID:                fmt.Sprintf("AX%018d", s.newID()),
+		Name:              name,
+		Description:       params.Get("description"),
+		ProjectKeyPattern: params.Get("projectKeyPattern"),
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		Organization:      organization,
+	}
+	s.templates[template.ID] = template
+	writeJSON(w, permissionTemplateResponse{PermissionTemplate: template})
+}
+
+func (s *Server) findTemplate(name string, organization string) *permissionTemplate {
+	for _, template := range s.templates {
+		if strings.EqualFold(template.Name, name) && template.Organization == organization {
+			return template
+		}
+	}
+	return nil
+}
+
+// templateByID returns the template with the id parameter key. A 404 response
+// is written when it does not exist.
+func (s *Server) templateByID(w http.ResponseWriter, params url.Values, key string) (*permissionTemplate, bool) {
+	if !required(w, params, key) {
+		return nil, false
+	}
+
+	template, ok := s.templates[params.Get(key)]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Permission template with id '%s' is not found", params.Get(key))
+		return nil, false
+	}
+	return template, true
+}
+
+func (s *Server) searchTemplates(w http.ResponseWriter, params url.Values) {
+	organization, ok := s.organization(w, params)
+	if !ok {
+		return
+	}
+
+	response := searchTemplatesResponse{
+		PermissionTemplates: []*permissionTemplate{},
+		DefaultTemplates:    []defaultTemplate{},
+	}
+	q := params.Get("q")
+	for _, template := range s.templates {
+		if template.Organization != organization {
+			continue
+		}
+		if template.IsDefault {
+			response.DefaultTemplates = append(response.DefaultTemplates, defaultTemplate{TemplateID: template.ID, Qualifier: "TRK"})
+		}
+		if q != "" && !matches(template.Name, q) {
+			continue
+		}
+		response.PermissionTemplates = append(response.PermissionTemplates, template)
+	}
+	sort.Slice(response.PermissionTemplates, func(i, j int) bool {
+		return response.PermissionTemplates[i].Name < response.PermissionTemplates[j].Name
+	})
+
+	writeJSON(w, response)
+}
+
+func (s *Server) updateTemplate(w http.ResponseWriter, params url.Values) {
+	template, ok := s.templateByID(w, params, "id")
+	if !ok {
+		return
+	}
+
+	if name := params.Get("name"); name != "" && !strings.EqualFold(name, template.Name) {
+		if s.findTemplate(name, template.Organization) != nil {
+			writeError(w, http.StatusBadRequest, "A template with the name '%s' already exists (case insensitive).", name)
+			return
+		}
+		template.Name = name
+	}
+	if _, ok := params["description"]; ok {
+		template.Description = params.Get("description")
+	}
+	if _, ok := params["projectKeyPattern"]; ok {
+		template.ProjectKeyPattern = params.Get("projectKeyPattern")
+	}
+	template.UpdatedAt = time.Now().Format(timeFormat)
+	writeJSON(w, permissionTemplateResponse{PermissionTemplate: template})
+}
+
+func (s *Server) deleteTemplate(w http.ResponseWriter, params url.Values) {
+	template, ok := s.templateByID(w, params, "templateId")
+	if !ok {
+		return
+	}
+	if template.IsDefault {
+		writeError(w, http.StatusBadRequest, "It is not possible to delete the default permission template for projects")
+		return
+	}
+
+	delete(s.templates, template.ID)
+	for scope := range s.permissions {
+		if scope.TemplateID == template.ID {
+			delete(s.permissions, scope)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,306 @@
+package sonarcloudtest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type project struct {
+	Organization     string `json:"organization,omitempty"`
+	Key              string `json:"key"`
+	Name             string `json:"name"`
+	Qualifier        string `json:"qualifier"`
+	Visibility       string `json:"visibility"`
+	LastAnalysisDate string `json:"lastAnalysisDate,omitempty"`
+	Revision         string `json:"revision,omitempty"`
+	// Tags are only returned by api/components/show
+	Tags []string `json:"-"`
+}
+
+type componentResponse struct {
+	Component component `json:"component"`
+}
+
+type component struct {
+	*project
+	Tags []string `json:"tags"`
+}
+
+type searchProjectTagsResponse struct {
+	Tags []string `json:"tags"`
+}
+
+type createProjectResponse struct {
+	Project *project `json:"project"`
+}
+
+type searchProjectsResponse struct {
+	Paging     paging     `json:"paging"`
+	Components []*project `json:"components"`
+}
+
+func (s *Server) createProject(w http.ResponseWriter, params url.Values) {
+	organization, ok := s.organization(w, params)
+	if !ok || !required(w, params, "name", "project") {
+		return
+	}
+
+	key := params.Get("project")
+	if _, ok := s.projects[key]; ok {
+		writeError(w, http.StatusBadRequest, "Could not create Project, key already exists: %s", key)
+		return
+	}
+
+	visibility := params.Get("visibility")
+	if visibility == "" {
+		visibility = "public"
+	}
+	if !validVisibility(w, visibility) {
+		return
+	}
+
+	p := &project{
+		Organization: organization,
+		Key:          key,
+		Name:         params.Get("name"),
+		Qualifier:    "TRK",
+		Visibility:   visibility,
+	}
+	s.projects[key] = p
+	writeJSON(w, createProjectResponse{Project: p})
+}
+
+func (s *Server) searchProjects(w http.ResponseWriter, params url.Values) {
+	organization, ok := s.organization(w, params)
+	if !ok {
+		return
+	}
+
+	var keys []string
+	if projects := params.Get("projects"); projects != "" {
+		keys = strings.Split(projects, ",")
+	}
+
+	results := []*project{}
+	for _, p := range s.projects {
+		if p.Organization != organization {
+			continue
+		}
+		if keys != nil && !contains(keys, p.Key) {
+			continue
+		}
+		if q := params.Get("q"); q != "" && !matches(p.Key, q) && !matches(p.Name, q) {
+			continue
+		}
+		results = append(results, p)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Key < results[j].Key })
+
+	start, end, paging, ok := page(w, params, len(results))
+	if !ok {
+		return
+	}
+	writeJSON(w, searchProjectsResponse{Paging: paging, Components: results[start:end]})
+}
+
+func (s *Server) deleteProject(w http.ResponseWriter, params url.Values) {
+	if !required(w, params, "project") {
+		return
+	}
+
+	key := params.Get("project")
+	if _, ok := s.projects[key]; !ok {
+		writeError(w, http.StatusNotFound, "Project '%s' not found", key)
+		return
+	}
+
+	delete(s.projects, key)
+	for _, gate := range s.qualityGates {
+		delete(gate.Projects, key)
+	}
+	for scope := range s.permissions {
+		if scope.ProjectKey == key {
+			delete(s.permissions, scope)
+		}
+	}
+	for sc := range s.newCodePeriods {
+		if sc.ProjectKey == key {
+			delete(s.newCodePeriods, sc)
+		}
+	}
+	for sc := range s.settings {
+		if sc.ProjectKey == key {
+			delete(s.settings, sc)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AnalyzeProject sets the name, analysis date and revision of a project, like
+// an analysis with sonar.projectName does.
+func (s *Server) AnalyzeProject(key string, name string, revision string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.projects[key]; ok {
+		p.Name = name
+		p.LastAnalysisDate = "2021-06-01T12:00:00+0000"
+		p.Revision = revision
+	}
+}
+
+// validVisibility reports whether visibility is public or private. A 400
+// response is written when it is not.
+func validVisibility(w http.ResponseWriter, visibility string) bool {
+	if visibility != "public" && visibility != "private" {
+		writeError(w, http.StatusBadRequest, "Value of parameter 'visibility' (%s) must be one of: [private, public]", visibility)
+		return false
+	}
+	return true
+}
+
+// updateProjectKey changes the key of a project, keeping its quality gate and
+// permissions like the real API.
+func (s *Server) updateProjectKey(w http.ResponseWriter, params url.Values) {
+	if !required(w, params, "from", "to") {
+		return
+	}
+
+	from, to := params.Get("from"), params.Get("to")
+	p, ok := s.projects[from]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Component key '%s' not found", from)
+		return
+	}
+	if _, ok := s.projects[to]; ok {
+		writeError(w, http.StatusBadRequest, "Impossible to update key: a component with key \"%s\" already exists.", to)
+		return
+	}
+
+	delete(s.projects, from)
+	p.Key = to
+	s.projects[to] = p
+	for _, gate := range s.qualityGates {
+		if gate.Projects[from] {
+			delete(gate.Projects, from)
+			gate.Projects[to] = true
+		}
+	}
+	for scope, principals := range s.permissions {
+		if scope.ProjectKey == from {
+			delete(s.permissions, scope)
+			scope.ProjectKey = to
+			s.permissions[scope] = principals
+		}
+	}
+	for sc, period := range s.newCodePeriods {
+		if sc.ProjectKey == from {
+			delete(s.newCodePeriods, sc)
+			sc.ProjectKey = to
+			s.newCodePeriods[sc] = period
+		}
+	}
+	for sc, values := range s.settings {
+		if sc.ProjectKey == from {
+			delete(s.settings, sc)
+			sc.ProjectKey = to
+			s.settings[sc] = values
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) updateProjectVisibility(w http.ResponseWriter, params url.Values) {
+	if !required(w, params, "project", "visibility") {
+		return
+	}
+
+	p, ok := s.projects[params.Get("project")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Project '%s' not found", params.Get("project"))
+		return
+	}
+	if !validVisibility(w, params.Get("visibility")) {
+		return
+	}
+
+	p.Visibility = params.Get("visibility")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// showComponent returns a project with its tags.
+func (s *Server) showComponent(w http.ResponseWriter, params url.Values) {
+	if !required(w, params, "component") {
+		return
+	}
+
+	p, ok := s.projects[params.Get("component")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Component key '%s' not found", params.Get("component"))
+		return
+	}
+	writeJSON(w, componentResponse{Component: component{project: p, Tags: append([]string{}, p.Tags...)}})
+}
+
+// setProjectTags replaces the tags of a project. Like the real API tags are
+// lower cased, and an empty tags parameter removes all tags.
+func (s *Server) setProjectTags(w http.ResponseWriter, params url.Values) {
+	if !required(w, params, "project") {
+		return
+	}
+	if _, ok := params["tags"]; !ok {
+		writeError(w, http.StatusBadRequest, "The 'tags' parameter is missing")
+		return
+	}
+
+	p, ok := s.projects[params.Get("project")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Project '%s' not found", params.Get("project"))
+		return
+	}
+
+	tags := []string{}
+	for _, tag := range strings.Split(params.Get("tags"), ",") {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	p.Tags = tags
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// searchProjectTags returns the tags of the projects in the organization that
+// match q. The organization is optional, all projects are searched without it.
+func (s *Server) searchProjectTags(w http.ResponseWriter, params url.Values) {
+	// The endpoint has its own page size limits
+	if ps := params.Get("ps"); ps == "" {
+		params.Set("ps", "10")
+	} else if pageSize, err := strconv.Atoi(ps); err != nil || pageSize < 1 || pageSize > 100 {
+		writeError(w, http.StatusBadRequest, "'ps' value (%s) must be between 1 and 100", ps)
+		return
+	}
+
+	organization := params.Get("organization")
+	tags := []string{}
+	for _, p := range s.projects {
+		if organization != "" && p.Organization != organization {
+			continue
+		}
+		for _, tag := range p.Tags {
+			if !contains(tags, tag) && (params.Get("q") == "" || matches(tag, params.Get("q"))) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+
+	start, end, _, ok := page(w, params, len(tags))
+	if !ok {
+		return
+	}
+	writeJSON(w, searchProjectTagsResponse{Tags: tags[start:end]})
+}
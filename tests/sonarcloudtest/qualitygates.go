@@ -0,0 +1,453 @@
+package sonarcloudtest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+type qualityGate struct {
+	ID           int
+	Name         string
+	Organization string
+	IsBuiltIn    bool
+	Conditions   []*condition
+	// Projects holds the keys of the associated projects
+	Projects map[string]bool
+}
+
+type condition struct {
+	ID     int    `json:"id"`
+	Metric string `json:"metric"`
+	OP     string `json:"op"`
+	Error  string `json:"error"`
+}
+
+type qualityGateActions struct {
+	Rename            bool `json:"rename"`
+	SetAsDefault      bool `json:"setAsDefault"`
+	Copy              bool `json:"copy"`
+	AssociateProjects bool `json:"associateProjects"`
+	Delete            bool `json:"delete"`
+	ManageConditions  bool `json:"manageConditions"`
+}
+
+type createQualityGateResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type showQualityGateResponse struct {
+	ID         int                `json:"id"`
+	Name       string             `json:"name"`
+	Conditions []*condition       `json:"conditions"`
+	IsBuiltIn  bool               `json:"isBuiltIn"`
+	Actions    qualityGateActions `json:"actions"`
+}
+
+type listedQualityGate struct {
+	ID        int                `json:"id"`
+	Name      string             `json:"name"`
+	IsDefault bool               `json:"isDefault"`
+	IsBuiltIn bool               `json:"isBuiltIn"`
+	Actions   qualityGateActions `json:"actions"`
+}
+
+type listQualityGatesResponse struct {
+	QualityGates []*listedQualityGate `json:"qualitygates"`
+	Default      int                  `json:"default"`
+}
+
+type qualityGateProject struct {
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Selected bool   `json:"selected"`
+}
+
+type searchQualityGateProjectsResponse struct {
+	Paging  paging                `json:"paging"`
+	Results []*qualityGateProject `json:"results"`
+}
+
+// ops are the operators a condition can use
+var ops = []string{"LT", "GT"}
+
+func (s *Server) createQualityGate(w http.ResponseWriter, params url.Values) {
+	organization, ok := s.organization(w, params)
+	if !ok || !required(w, params, "name") {
+		return
+	}
+
+	name := params.Get("name")
+	if !s.uniqueQualityGateName(w, organization, name) {
+		return
+	}
+
+	gate := &qualityGate{
+		ID:           s.newID(),
+		Name:         name,
+		Organization: organization,
+		Projects:     map[string]bool{},
+	}
+	s.qualityGates[gate.ID] = gate
+	writeJSON(w, createQualityGateResponse{ID: gate.ID, Name: gate.Name})
+}
+
+// uniqueQualityGateName writes a 400 response when a quality gate of the
+// organization, or a built-in one, is already named name.
+func (s *Server) uniqueQualityGateName(w http.ResponseWriter, organization string, name string) bool {
+	for _, gate := range s.qualityGates {
+		if gate.Name == name && (gate.IsBuiltIn || gate.Organization == organization) {
+			writeError(w, http.StatusBadRequest, "Name has already been taken")
+			return false
+		}
+	}
+	return true
+}
+
+// qualityGate returns the quality gate identified by the gate id parameter
+// key. A 404 response is written when it does not exist in the organization.
+func (s *Server) qualityGate(w http.ResponseWriter, params url.Values, key string) (*qualityGate, bool) {
+	organization, ok := s.organization(w, params)
+	if !ok || !required(w, params, key) {
+		return nil, false
+	}
+
+	id, err := strconv.Atoi(params.Get(key))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "The '%s' parameter cannot be parsed as an integer value: %s", key, params.Get(key))
+		return nil, false
+	}
+
+	gate, ok := s.qualityGates[id]
+	if !ok || !gate.IsBuiltIn && gate.Organization != organization {
+		writeError(w, http.StatusNotFound, "No quality gate has been found for id %d", id)
+		return nil, false
+	}
+	return gate, true
+}
+
+func (s *Server) showQualityGate(w http.ResponseWriter, params url.Values) {
+	gate, ok := s.qualityGate(w, params, "id")
+	if !ok {
+		return
+	}
+
+	writeJSON(w, showQualityGateResponse{
+		ID:         gate.ID,
+		Name:       gate.Name,
+		Conditions: append([]*condition{}, gate.Conditions...),
+		IsBuiltIn:  gate.IsBuiltIn,
+		Actions:    s.qualityGateActions(gate),
+	})
+}
+
+// qualityGateActions returns the actions the administrator, which every token
+// of the server authenticates, can perform on gate.
+func (s *Server) qualityGateActions(gate *qualityGate) qualityGateActions {
+	return qualityGateActions{
+		Rename:            !gate.IsBuiltIn,
+		SetAsDefault:      s.defaultQualityGate != gate.ID,
+		Copy:              true,
+		AssociateProjects: s.defaultQualityGate != gate.ID,
+		Delete:            !gate.IsBuiltIn && s.defaultQualityGate != gate.ID,
+		ManageConditions:  !gate.IsBuiltIn,
+	}
+}
+
+// listQualityGates lists the quality gates of the organization and the
+// built-in ones, sorted by name.
+func (s *Server) listQualityGates(w http.ResponseWriter, params url.Values) {
+	organization, ok := s.organization(w, params)
+	if !ok {
+		return
+	}
+
+	gates := []*listedQualityGate{}
+	for _, gate := range s.qualityGates {
+		if !gate.IsBuiltIn && gate.Organization != organization {
+			continue
+		}
+		gates = append(gates, &listedQualityGate{
+			ID:        gate.ID,
+			Name:      gate.Name,
+			IsDefault: s.defaultQualityGate == gate.ID,
+			IsBuiltIn: gate.IsBuiltIn,
+			Actions:   s.qualityGateActions(gate),
+		})
+	}
+	sort.Slice(gates, func(i, j int) bool { return gates[i].Name < gates[j].Name })
+
+	writeJSON(w, listQualityGatesResponse{QualityGates: gates, Default: s.defaultQualityGate})
+}
+
+func (s *Server) renameQualityGate(w http.ResponseWriter, params url.Values) {
+	gate, ok := s.qualityGate(w, params, "id")
+	if !ok || !required(w, params, "name") {
+		return
+	}
+	if gate.IsBuiltIn {
+		writeError(w, http.StatusBadRequest, "Operation forbidden for built-in Quality Gate '%s'", gate.Name)
+		return
+	}
+
+	name := params.Get("name")
+	if name != gate.Name && !s.uniqueQualityGateName(w, gate.Organization, name) {
+		return
+	}
+
+	gate.Name = name
+	writeJSON(w, createQualityGateResponse{ID: gate.ID, Name: gate.Name})
+}
+
+// copyQualityGate creates a quality gate in the organization with the
+// conditions of the quality gate with the id parameter.
+func (s *Server) copyQualityGate(w http.ResponseWriter, params url.Values) {
+	source, ok := s.qualityGate(w, params, "id")
+	if !ok || !required(w, params, "name") {
+		return
+	}
+
+	organization, _ := s.organization(w, params)
+	name := params.Get("name")
+	if !s.uniqueQualityGateName(w, organization, name) {
+		return
+	}
+
+	gate := &qualityGate{
+		ID:           s.newID(),
+		Name:         name,
+		Organization: organization,
+		Projects:     map[string]bool{},
+	}
+	for _, c := range source.Conditions {
+		copied := *c
+		copied.ID = s.newID()
+		gate.Conditions = append(gate.Conditions, &copied)
+	}
+	s.qualityGates[gate.ID] = gate
+	writeJSON(w, createQualityGateResponse{ID: gate.ID, Name: gate.Name})
+}
+
+// ResetDefaultQualityGate makes the built-in quality gate the default again,
+// like an administrator has to before the default quality gate is removed.
+func (s *Server) ResetDefaultQualityGate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, gate := range s.qualityGates {
+		if gate.IsBuiltIn {
+			s.defaultQualityGate = gate.ID
+		}
+	}
+}
+
+func (s *Server) setDefaultQualityGate(w http.ResponseWriter, params url.Values) {
+	gate, ok := s.qualityGate(w, params, "id")
+	if !ok {
+		return
+	}
+
+	s.defaultQualityGate = gate.ID
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) destroyQualityGate(w http.ResponseWriter, params url.Values) {
+	gate, ok := s.qualityGate(w, params, "id")
+	if !ok {
+		return
+	}
+	if gate.IsBuiltIn {
+		writeError(w, http.StatusBadRequest, "Operation forbidden for built-in Quality Gate '%s'", gate.Name)
+		return
+	}
+	if gate.ID == s.defaultQualityGate {
+		writeError(w, http.StatusBadRequest, "The default quality gate cannot be removed")
+		return
+	}
+
+	delete(s.qualityGates, gate.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validCondition writes a 400 response unless the condition parameters are
+// valid.
+func validCondition(w http.ResponseWriter, params url.Values) bool {
+	if !required(w, params, "metric", "error") {
+		return false
+	}
+	if op := params.Get("op"); op != "" && !contains(ops, op) {
+		writeError(w, http.StatusBadRequest, "Value of parameter 'op' (%s) must be one of: [LT, GT]", op)
+		return false
+	}
+	return true
+}
+
+func (s *Server) createCondition(w http.ResponseWriter, params url.Values) {
+	gate, ok := s.qualityGate(w, params, "gateId")
+	if !ok || !validCondition(w, params) {
+		return
+	}
+	if gate.IsBuiltIn {
+		writeError(w, http.StatusBadRequest, "Operation forbidden for built-in Quality Gate '%s'", gate.Name)
+		return
+	}
+
+	metric := params.Get("metric")
+	for _, c := range gate.Conditions {
+		if c.Metric == metric {
+			writeError(w, http.StatusBadRequest, "Condition on metric '%s' already exists.", metric)
+			return
+		}
+	}
+
+	c := &condition{
+		ID:     s.newID(),
+		Metric: metric,
+		OP:     params.Get("op"),
+		Error:  params.Get("error"),
+	}
+	gate.Conditions = append(gate.Conditions, c)
+	writeJSON(w, c)
+}
+
+// condition returns the condition with the id parameter and its quality gate.
+// A 404 response is written when it does not exist.
+func (s *Server) condition(w http.ResponseWriter, params url.Values) (*qualityGate, int, bool) {
+	organization, ok := s.organization(w, params)
+	if !ok || !required(w, params, "id") {
+		return nil, 0, false
+	}
+
+	id := params.Get("id")
+	for _, gate := range s.qualityGates {
+		if gate.Organization != organization {
+			continue
+		}
+		for i, c := range gate.Conditions {
+			if strconv.Itoa(c.ID) == id {
+				return gate, i, true
+			}
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "No quality gate condition with id '%s'", id)
+	return nil, 0, false
+}
+
+func (s *Server) updateCondition(w http.ResponseWriter, params url.Values) {
+	gate, i, ok := s.condition(w, params)
+	if !ok || !validCondition(w, params) {
+		return
+	}
+
+	c := gate.Conditions[i]
+	c.Metric = params.Get("metric")
+	c.OP = params.Get("op")
+	c.Error = params.Get("error")
+	writeJSON(w, c)
+}
+
+func (s *Server) deleteCondition(w http.ResponseWriter, params url.Values) {
+	gate, i, ok := s.condition(w, params)
+	if !ok {
+		return
+	}
+
+	gate.Conditions = append(gate.Conditions[:i], gate.Conditions[i+1:]...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// gateIDOrName returns the quality gate of the gateId parameter, or of the
+// gateName parameter that servers accept instead from 8.4 on.
+func (s *Server) gateIDOrName(w http.ResponseWriter, params url.Values) (*qualityGate, bool) {
+	name := params.Get("gateName")
+	if name == "" || !s.versionAtLeast("8.4") {
+		return s.qualityGate(w, params, "gateId")
+	}
+
+	organization, ok := s.organization(w, params)
+	if !ok {
+		return nil, false
+	}
+	for _, gate := range s.qualityGates {
+		if gate.Name == name && (gate.IsBuiltIn || gate.Organization == organization) {
+			return gate, true
+		}
+	}
+	writeError(w, http.StatusNotFound, "No quality gate has been found for name %s", name)
+	return nil, false
+}
+
+// selectedProject returns the quality gate and project of a (de)selection.
+func (s *Server) selectedProject(w http.ResponseWriter, params url.Values) (*qualityGate, string, bool) {
+	gate, ok := s.gateIDOrName(w, params)
+	if !ok || !required(w, params, "projectKey") {
+		return nil, "", false
+	}
+
+	key := params.Get("projectKey")
+	if p, ok := s.projects[key]; !ok || p.Organization != gate.Organization && !gate.IsBuiltIn {
+		writeError(w, http.StatusNotFound, "Project '%s' not found", key)
+		return nil, "", false
+	}
+	return gate, key, true
+}
+
+func (s *Server) selectQualityGate(w http.ResponseWriter, params url.Values) {
+	gate, key, ok := s.selectedProject(w, params)
+	if !ok {
+		return
+	}
+
+	// A project is associated with one quality gate at most
+	for _, other := range s.qualityGates {
+		delete(other.Projects, key)
+	}
+	gate.Projects[key] = true
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deselectQualityGate(w http.ResponseWriter, params url.Values) {
+	gate, key, ok := s.selectedProject(w, params)
+	if !ok {
+		return
+	}
+
+	delete(gate.Projects, key)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// searchQualityGateProjects lists the projects of a quality gate. Like the
+// real API it returns the selected projects unless selected is "all" or
+// "deselected".
+func (s *Server) searchQualityGateProjects(w http.ResponseWriter, params url.Values) {
+	gate, ok := s.gateIDOrName(w, params)
+	if !ok {
+		return
+	}
+
+	selected := params.Get("selected")
+	if selected == "" {
+		selected = "selected"
+	}
+
+	results := []*qualityGateProject{}
+	for _, p := range s.projects {
+		if p.Organization != gate.Organization && !gate.IsBuiltIn {
+			continue
+		}
+		isSelected := gate.Projects[p.Key]
+		if selected == "selected" && !isSelected || selected == "deselected" && isSelected {
+			continue
+		}
+		results = append(results, &qualityGateProject{Key: p.Key, Name: p.Name, Selected: isSelected})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Key < results[j].Key })
+
+	start, end, paging, ok := page(w, params, len(results))
+	if !ok {
+		return
+	}
+	writeJSON(w, searchQualityGateProjectsResponse{Paging: paging, Results: results[start:end]})
+}
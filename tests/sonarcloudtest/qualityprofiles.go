@@ -0,0 +1,124 @@
+package sonarcloudtest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+type qualityProfile struct {
+	Key          string `json:"key"`
+	Name         string `json:"name"`
+	Language     string `json:"language"`
+	Organization string `json:"organization,omitempty"`
+	IsDefault    bool   `json:"isDefault"`
+	IsInherited  bool   `json:"isInherited"`
+}
+
+type createQualityProfileResponse struct {
+	Profile *qualityProfile `json:"profile"`
+}
+
+type searchQualityProfilesResponse struct {
+	Profiles []*qualityProfile `json:"profiles"`
+}
+
+// addQualityProfile stores a new quality profile. A 400 response is written
+// when the name is taken for the language.
+func (s *Server) addQualityProfile(w http.ResponseWriter, name string, language string, organization string) (*qualityProfile, bool) {
+	if s.findQualityProfile(name, language, organization) != nil {
+		writeError(w, http.StatusBadRequest, "Quality profile already exists: {lang=%s, name=%s}", language, name)
+		return nil, false
+	}
+
+	profile := &qualityProfile{
+		Key:          fmt.Sprintf("AX%018d", s.newID()),
+		Name:         name,
+		Language:     language,
+		Organization: organization,
+	}
+	s.qualityProfiles[profile.Key] = profile
+	return profile, true
+}
+
+func (s *Server) findQualityProfile(name string, language string, organization string) *qualityProfile {
+	for _, profile := range s.qualityProfiles {
+		if profile.Name == name && profile.Language == language && profile.Organization == organization {
+			return profile
+		}
+	}
+	return nil
+}
+
+func (s *Server) createQualityProfile(w http.ResponseWriter, params url.Values) {
+	organization, ok := s.organization(w, params)
+	if !ok || !required(w, params, "name", "language") {
+		return
+	}
+
+	profile, ok := s.addQualityProfile(w, params.Get("name"), params.Get("language"), organization)
+	if !ok {
+		return
+	}
+	writeJSON(w, createQualityProfileResponse{Profile: profile})
+}
+
+func (s *Server) searchQualityProfiles(w http.ResponseWriter, params url.Values) {
+	organization, ok := s.organization(w, params)
+	if !ok {
+		return
+	}
+
+	results := []*qualityProfile{}
+	for _, profile := range s.qualityProfiles {
+		if profile.Organization != organization {
+			continue
+		}
+		if name := params.Get("qualityProfile"); name != "" && profile.Name != name {
+			continue
+		}
+		if language := params.Get("language"); language != "" && profile.Language != language {
+			continue
+		}
+		results = append(results, profile)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Key < results[j].Key })
+
+	writeJSON(w, searchQualityProfilesResponse{Profiles: results})
+}
+
+func (s *Server) deleteQualityProfile(w http.ResponseWriter, params url.Values) {
+	organization, ok := s.organization(w, params)
+	if !ok || !required(w, params, "qualityProfile", "language") {
+		return
+	}
+
+	name, language := params.Get("qualityProfile"), params.Get("language")
+	profile := s.findQualityProfile(name, language, organization)
+	if profile == nil {
+		writeError(w, http.StatusNotFound, "Quality Profile for language '%s' and name '%s' does not exist", language, name)
+		return
+	}
+
+	delete(s.qualityProfiles, profile.Key)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) copyQualityProfile(w http.ResponseWriter, params url.Values) {
+	if !required(w, params, "fromKey", "toName") {
+		return
+	}
+
+	from, ok := s.qualityProfiles[params.Get("fromKey")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Quality Profile with key '%s' does not exist", params.Get("fromKey"))
+		return
+	}
+
+	profile, ok := s.addQualityProfile(w, params.Get("toName"), from.Language, from.Organization)
+	if !ok {
+		return
+	}
+	writeJSON(w, profile)
+}
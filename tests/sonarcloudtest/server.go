@@ -0,0 +1,501 @@
+// Package sonarcloudtest provides an in-memory fake of the SonarCloud web API
+// for offline tests of the provider. It implements the endpoints the provider
+// uses with the response bodies, paging and error messages of the real API,
+// and keeps all objects in memory for the lifetime of the server.
+//
+//	server := sonarcloudtest.NewServer(sonarcloudtest.Options{})
+//	defer server.Close()
+//
+//	resource.Test(t, resource.TestCase{
+//		ProviderFactories: testAccProviderFactories,
+//		Steps: []resource.TestStep{{
+//			Config: server.ProviderConfig() + `resource "sonarcloud_group" "test" { name = "test" }`,
+//		}},
+//	})
+package sonarcloudtest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
+
+// Platforms the server can pretend to be
+const (
+	PlatformSonarCloud = "sonarcloud"
+	PlatformSonarQube  = "sonarqube"
+)
+
+// Options configure the fake server. The zero value is a SonarQube community
+// edition that accepts any credentials.
+type Options struct {
+	// Platform is PlatformSonarQube or PlatformSonarCloud. SonarCloud
+	// requires an organization on every organization scoped request.
+	Platform string
+	// Version returned by api/server/version, defaults to DefaultVersion.
+	Version string
+	// Edition returned by api/navigation/global on SonarQube, defaults to
+	// "community".
+	Edition string
+	// Token is the only user token accepted when set. Without a token any
+	// credentials are accepted.
+	Token string
+	// TLS serves HTTPS with a self-signed certificate, see CACertPEM.
+	TLS bool
+	// ClientCAs makes the server require client certificates signed by one
+	// of these CAs. It implies TLS.
+	ClientCAs *x509.CertPool
+	// ContextPath serves the API under a path prefix like "/sonarqube",
+	// ProviderConfig then uses the url attribute.
+	ContextPath string
+}
+
+// DefaultVersion is the server version reported when Options.Version is empty
+const DefaultVersion = "8.9.0.43852"
+
+// Server is a fake SonarCloud server listening on a local address.
+type Server struct {
+	*httptest.Server
+
+	options Options
+
+	// mu guards the state below, every request holds it while it runs
+	mu                 sync.Mutex
+	nextID             int
+	projects           map[string]*project
+	qualityGates       map[int]*qualityGate
+	qualityProfiles    map[string]*qualityProfile
+	groups             map[int]*group
+	users              map[string]*user
+	tokens             map[string][]*userToken
+	templates          map[string]*permissionTemplate
+	permissions        map[permissionScope]map[principal][]string
+	newCodePeriods     map[scope]*newCodePeriod
+	settings           map[scope]map[string]string
+	installedPlugins   map[string]*plugin
+	installingPlugins  map[string]*plugin
+	removingPlugins    map[string]*plugin
+	defaultQualityGate int
+}
+
+// NewServer starts a fake server. Call Close when done with it.
+func NewServer(options Options) *Server {
+	if options.Platform == "" {
+		options.Platform = PlatformSonarQube
+	}
+	if options.Version == "" {
+		options.Version = DefaultVersion
+	}
+	if options.Edition == "" && options.Platform == PlatformSonarQube {
+		options.Edition = "community"
+	}
+
+	s := &Server{
+		options:           options,
+		projects:          map[string]*project{},
+		qualityGates:      map[int]*qualityGate{},
+		qualityProfiles:   map[string]*qualityProfile{},
+		groups:            map[int]*group{},
+		users:             map[string]*user{},
+		tokens:            map[string][]*userToken{},
+		templates:         map[string]*permissionTemplate{},
+		permissions:       map[permissionScope]map[principal][]string{},
+		newCodePeriods:    map[scope]*newCodePeriod{},
+		settings:          map[scope]map[string]string{},
+		installedPlugins:  map[string]*plugin{},
+		installingPlugins: map[string]*plugin{},
+		removingPlugins:   map[string]*plugin{},
+	}
+	s.seed()
+
+	mux := http.NewServeMux()
+	s.routes(mux)
+	var handler http.Handler = mux
+	if options.ContextPath != "" {
+		handler = http.StripPrefix(strings.TrimSuffix(options.ContextPath, "/"), mux)
+	}
+	s.Server = httptest.NewUnstartedServer(handler)
+	switch {
+	case options.ClientCAs != nil:
+		s.Server.TLS = &tls.Config{
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  options.ClientCAs,
+		}
+		s.Server.StartTLS()
+	case options.TLS:
+		s.Server.StartTLS()
+	default:
+		s.Server.Start()
+	}
+	return s
+}
+
+// Host returns the host and port the server listens on, as expected by the
+// host attribute of the provider.
+func (s *Server) Host() string {
+	return s.Listener.Addr().String()
+}
+
+// Platform returns the platform the server pretends to be, as expected by the
+// platform attribute of the provider.
+func (s *Server) Platform() string {
+	return s.options.Platform
+}
+
+// Scheme returns the scheme the server is reached with, as expected by the
+// scheme attribute of the provider.
+func (s *Server) Scheme() string {
+	if s.Server.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// BaseURL returns the URL of the server including the context path, as
+// expected by the url attribute of the provider.
+func (s *Server) BaseURL() string {
+	return s.URL + s.options.ContextPath
+}
+
+// CACertPEM returns the PEM encoded certificate of a TLS server, as expected
+// by the ca_cert_pem attribute of the provider. It is empty for plain HTTP.
+func (s *Server) CACertPEM() string {
+	certificate := s.Certificate()
+	if certificate == nil {
+		return ""
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}))
+}
+
+// ProviderConfig returns a provider block that configures the provider to use
+// the server. A TLS server is trusted with ca_cert_pem, extra attributes like
+// the client certificate are added verbatim.
+func (s *Server) ProviderConfig(extra ...string) string {
+	token := s.options.Token
+	if token == "" {
+		token = "sonarcloudtest"
+	}
+
+	address := fmt.Sprintf("  scheme   = %q\n  host     = %q\n", s.Scheme(), s.Host())
+	if s.options.ContextPath != "" {
+		address = fmt.Sprintf("  url      = %q\n", s.BaseURL())
+	}
+
+	attributes := ""
+	if caCert := s.CACertPEM(); caCert != "" {
+		attributes += fmt.Sprintf("  ca_cert_pem = %q\n", caCert)
+	}
+	for _, attribute := range extra {
+		attributes += "  " + attribute + "\n"
+	}
+
+	return fmt.Sprintf(`
+provider "sonarcloud" {
+%s  token    = %q
+  platform = %q
+%s}
+`, address, token, s.options.Platform, attributes)
+}
+
+// seed creates the objects every server ships with.
+func (s *Server) seed() {
+	gate := &qualityGate{
+		ID:        s.newID(),
+		Name:      "Sonar way",
+		IsBuiltIn: true,
+		Projects:  map[string]bool{},
+	}
+	s.qualityGates[gate.ID] = gate
+	s.defaultQualityGate = gate.ID
+
+	template := &permissionTemplate{
+		ID:        fmt.Sprintf("AX%018d", s.newID()),
+		Name:      "Default template",
+		CreatedAt: time.Now().Format(timeFormat),
+		IsDefault: true,
+	}
+	template.UpdatedAt = template.CreatedAt
+	s.templates[template.ID] = template
+
+	// SonarCloud creates groups and users per organization, which the
+	// server does not know about
+	if s.options.Platform == PlatformSonarQube {
+		s.users["admin"] = &user{
+			Login:  "admin",
+			Name:   "Administrator",
+			Active: true,
+			Local:  true,
+		}
+
+		administrators := &group{ID: s.newID(), Name: "sonar-administrators", Description: "System administrators"}
+		s.groups[administrators.ID] = administrators
+		users := &group{ID: s.newID(), Name: "sonar-users", Description: "Any new users created will automatically join this group", IsDefault: true}
+		s.groups[users.ID] = users
+	}
+}
+
+func (s *Server) newID() int {
+	s.nextID++
+	return s.nextID
+}
+
+// routes registers the handlers of all endpoints.
+func (s *Server) routes(mux *http.ServeMux) {
+	s.handle(mux, "GET", "api/server/version", s.serverVersion)
+	s.handle(mux, "GET", "api/navigation/global", s.navigationGlobal)
+
+	s.handle(mux, "POST", "api/projects/create", s.createProject)
+	s.handle(mux, "GET", "api/projects/search", s.searchProjects)
+	s.handle(mux, "POST", "api/projects/delete", s.deleteProject)
+	s.handle(mux, "POST", "api/projects/update_key", s.updateProjectKey)
+	s.handle(mux, "POST", "api/projects/update_visibility", s.updateProjectVisibility)
+	s.handle(mux, "GET", "api/components/show", s.showComponent)
+	// Older servers keep the new code definition in the sonar.leak.period
+	// setting only
+	if s.versionAtLeast("8.0") {
+		s.handle(mux, "POST", "api/new_code_periods/set", s.setNewCodePeriod)
+		s.handle(mux, "GET", "api/new_code_periods/show", s.showNewCodePeriod)
+		s.handle(mux, "POST", "api/new_code_periods/unset", s.unsetNewCodePeriod)
+	}
+	s.handle(mux, "POST", "api/settings/set", s.setSetting)
+	s.handle(mux, "GET", "api/settings/values", s.settingValues)
+	s.handle(mux, "POST", "api/settings/reset", s.resetSettings)
+	s.handle(mux, "POST", "api/project_tags/set", s.setProjectTags)
+	s.handle(mux, "GET", "api/project_tags/search", s.searchProjectTags)
+
+	s.handle(mux, "POST", "api/qualitygates/create", s.createQualityGate)
+	s.handle(mux, "GET", "api/qualitygates/show", s.showQualityGate)
+	s.handle(mux, "POST", "api/qualitygates/destroy", s.destroyQualityGate)
+	s.handle(mux, "GET", "api/qualitygates/list", s.listQualityGates)
+	s.handle(mux, "POST", "api/qualitygates/rename", s.renameQualityGate)
+	s.handle(mux, "POST", "api/qualitygates/copy", s.copyQualityGate)
+	s.handle(mux, "POST", "api/qualitygates/set_as_default", s.setDefaultQualityGate)
+	s.handle(mux, "POST", "api/qualitygates/create_condition", s.createCondition)
+	s.handle(mux, "POST", "api/qualitygates/update_condition", s.updateCondition)
+	s.handle(mux, "POST", "api/qualitygates/delete_condition", s.deleteCondition)
+	s.handle(mux, "POST", "api/qualitygates/select", s.selectQualityGate)
+	s.handle(mux, "POST", "api/qualitygates/deselect", s.deselectQualityGate)
+	s.handle(mux, "GET", "api/qualitygates/search", s.searchQualityGateProjects)
+
+	s.handle(mux, "POST", "api/qualityprofiles/create", s.createQualityProfile)
+	s.handle(mux, "GET", "api/qualityprofiles/search", s.searchQualityProfiles)
+	s.handle(mux, "POST", "api/qualityprofiles/delete", s.deleteQualityProfile)
+	s.handle(mux, "POST", "api/qualityprofiles/copy", s.copyQualityProfile)
+
+	s.handle(mux, "POST", "api/permissions/add_user", s.addUserPermission)
+	s.handle(mux, "POST", "api/permissions/add_group", s.addGroupPermission)
+	s.handle(mux, "POST", "api/permissions/add_user_to_template", s.addUserPermission)
+	s.handle(mux, "POST", "api/permissions/add_group_to_template", s.addGroupPermission)
+	s.handle(mux, "POST", "api/permissions/remove_user", s.removeUserPermission)
+	s.handle(mux, "POST", "api/permissions/remove_group", s.removeGroupPermission)
+	s.handle(mux, "POST", "api/permissions/remove_user_from_template", s.removeUserPermission)
+	s.handle(mux, "POST", "api/permissions/remove_group_from_template", s.removeGroupPermission)
+	s.handle(mux, "GET", "api/permissions/users", s.searchUserPermissions)
+	s.handle(mux, "GET", "api/permissions/groups", s.searchGroupPermissions)
+	s.handle(mux, "GET", "api/permissions/template_users", s.searchUserPermissions)
+	s.handle(mux, "GET", "api/permissions/template_groups", s.searchGroupPermissions)
+	s.handle(mux, "POST", "api/permissions/create_template", s.createTemplate)
+	s.handle(mux, "GET", "api/permissions/search_templates", s.searchTemplates)
+	s.handle(mux, "POST", "api/permissions/update_template", s.updateTemplate)
+	s.handle(mux, "POST", "api/permissions/delete_template", s.deleteTemplate)
+
+	s.handle(mux, "POST", "api/user_groups/create", s.createGroup)
+	s.handle(mux, "GET", "api/user_groups/search", s.searchGroups)
+	s.handle(mux, "POST", "api/user_groups/update", s.updateGroup)
+	s.handle(mux, "POST", "api/user_groups/delete", s.deleteGroup)
+
+	s.handle(mux, "POST", "api/users/create", s.createUser)
+	s.handle(mux, "GET", "api/users/search", s.searchUsers)
+	s.handle(mux, "POST", "api/users/update", s.updateUser)
+	s.handle(mux, "POST", "api/users/change_password", s.changePassword)
+	s.handle(mux, "POST", "api/users/deactivate", s.deactivateUser)
+
+	s.handle(mux, "POST", "api/user_tokens/generate", s.generateToken)
+	s.handle(mux, "GET", "api/user_tokens/search", s.searchTokens)
+	s.handle(mux, "POST", "api/user_tokens/revoke", s.revokeToken)
+
+	s.handle(mux, "POST", "api/plugins/install", s.installPlugin)
+	s.handle(mux, "GET", "api/plugins/installed", s.installedPluginList)
+	s.handle(mux, "GET", "api/plugins/pending", s.pendingPluginList)
+	s.handle(mux, "POST", "api/plugins/uninstall", s.uninstallPlugin)
+}
+
+// handlerFunc handles a request with its query and form parameters merged.
+type handlerFunc func(w http.ResponseWriter, params url.Values)
+
+// handle registers h for path. Like the real API it rejects other methods,
+// unauthenticated requests and unparsable parameters before h runs. Requests
+// are served one at a time.
+func (s *Server) handle(mux *http.ServeMux, method string, path string, h handlerFunc) {
+	mux.HandleFunc("/"+path, func(w http.ResponseWriter, r *http.Request) {
+		// Like the real API, endpoints that change something only
+		// accept POST, while read-only endpoints accept both.
+		if r.Method != "POST" && (method == "POST" || r.Method != "GET") {
+			writeError(w, http.StatusMethodNotAllowed, "HTTP method %s is not supported", r.Method)
+			return
+		}
+
+		if !s.authenticated(r) {
+			// The real API answers unauthenticated requests without
+			// a body
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			writeError(w, http.StatusBadRequest, "%s", err)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		h(w, r.Form)
+	})
+}
+
+// authenticated reports whether the request carries accepted credentials.
+func (s *Server) authenticated(r *http.Request) bool {
+	login, _, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.options.Token == "" || login == s.options.Token
+}
+
+// versionAtLeast reports whether the server pretends to be v or a later
+// version.
+func (s *Server) versionAtLeast(v string) bool {
+	serverVersion, err := version.NewVersion(s.options.Version)
+	if err != nil {
+		return false
+	}
+	return serverVersion.GreaterThanOrEqual(version.Must(version.NewVersion(v)))
+}
+
+// SetToken replaces the only accepted user token, e.g. to test that a revoked
+// token is replaced by the credentials command of the provider.
+func (s *Server) SetToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.options.Token = token
+}
+
+// writeJSON writes v as the JSON body of a 200 response.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes the error body the real API sends, e.g.
+// {"errors":[{"msg":"Project 'foo' not found"}]}
+func writeError(w http.ResponseWriter, status int, format string, a ...interface{}) {
+	body := errorResponse{Errors: []errorMessage{{Message: fmt.Sprintf(format, a...)}}}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+type errorResponse struct {
+	Errors []errorMessage `json:"errors"`
+}
+
+type errorMessage struct {
+	Message string `json:"msg"`
+}
+
+// required writes a 400 response naming the first missing parameter. It
+// reports whether all parameters are set.
+func required(w http.ResponseWriter, params url.Values, keys ...string) bool {
+	for _, key := range keys {
+		if params.Get(key) == "" {
+			writeError(w, http.StatusBadRequest, "The '%s' parameter is missing", key)
+			return false
+		}
+	}
+	return true
+}
+
+// organization returns the organization of the request. SonarCloud requires
+// it, so a 400 response is written when it is missing there.
+func (s *Server) organization(w http.ResponseWriter, params url.Values) (string, bool) {
+	if s.options.Platform == PlatformSonarQube {
+		return "", true
+	}
+	if !required(w, params, "organization") {
+		return "", false
+	}
+	return params.Get("organization"), true
+}
+
+// paging is the paging object of search responses.
+type paging struct {
+	PageIndex int `json:"pageIndex"`
+	PageSize  int `json:"pageSize"`
+	Total     int `json:"total"`
+}
+
+// maxPageSize is the largest page size the search endpoints accept
+const maxPageSize = 500
+
+// page returns the bounds of the requested page of total results and its
+// paging object. On invalid paging parameters a 400 response is written.
+func page(w http.ResponseWriter, params url.Values, total int) (int, int, paging, bool) {
+	pageIndex, pageSize := 1, 100
+	var err error
+	if p := params.Get("p"); p != "" {
+		if pageIndex, err = strconv.Atoi(p); err != nil || pageIndex < 1 {
+			writeError(w, http.StatusBadRequest, "'%s' is not a valid page index", p)
+			return 0, 0, paging{}, false
+		}
+	}
+	if ps := params.Get("ps"); ps != "" {
+		if pageSize, err = strconv.Atoi(ps); err != nil || pageSize < 1 {
+			writeError(w, http.StatusBadRequest, "'%s' is not a valid page size", ps)
+			return 0, 0, paging{}, false
+		}
+		if pageSize > maxPageSize {
+			writeError(w, http.StatusBadRequest, "'ps' value (%d) must be less than %d", pageSize, maxPageSize)
+			return 0, 0, paging{}, false
+		}
+	}
+
+	start := (pageIndex - 1) * pageSize
+	if start > total {
+		start = total
+	}
+	end := start + pageSize
+	if end > total {
+		end = total
+	}
+	return start, end, paging{PageIndex: pageIndex, PageSize: pageSize, Total: total}, true
+}
+
+// matches reports whether value contains the search query q, ignoring case.
+func matches(value string, q string) bool {
+	return strings.Contains(strings.ToLower(value), strings.ToLower(q))
+}
+
+// contains reports whether values contains value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package sonarcloudtest
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// scope is the organization default, project or project branch a new code
+// definition or setting applies to. The zero value is the global scope of
+// SonarQube.
+type scope struct {
+	Organization string
+	ProjectKey   string
+	Branch       string
+}
+
+// parent returns the scope s inherits from.
+func (s scope) parent() (scope, bool) {
+	switch {
+	case s.Branch != "":
+		return scope{Organization: s.Organization, ProjectKey: s.ProjectKey}, true
+	case s.ProjectKey != "":
+		return scope{Organization: s.Organization}, true
+	default:
+		return scope{}, false
+	}
+}
+
+type newCodePeriod struct {
+	ProjectKey string `json:"projectKey,omitempty"`
+	BranchKey  string `json:"branchKey,omitempty"`
+	Type       string `json:"type"`
+	Value      string `json:"value,omitempty"`
+	Inherited  bool   `json:"inherited"`
+}
+
+type setting struct {
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+	Inherited bool   `json:"inherited"`
+}
+
+type settingsResponse struct {
+	Settings []setting `json:"settings"`
+}
+
+// requestScope returns the scope of the project, branch and organization
+// parameters. A 404 response is written when the project does not exist.
+func (s *Server) requestScope(w http.ResponseWriter, params url.Values, projectParam string) (scope, bool) {
+	key := params.Get(projectParam)
+	if key == "" {
+		if params.Get("branch") != "" {
+			writeError(w, http.StatusBadRequest, "If branch key is specified, project key needs to be specified too")
+			return scope{}, false
+		}
+		organization, ok := s.organization(w, params)
+		return scope{Organization: organization}, ok
+	}
+
+	p, ok := s.projects[key]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Project '%s' not found", key)
+		return scope{}, false
+	}
+	// Branches are not modelled, every branch of a project exists
+	return scope{Organization: p.Organization, ProjectKey: key, Branch: params.Get("branch")}, true
+}
+
+func (s *Server) setNewCodePeriod(w http.ResponseWriter, params url.Values) {
+	sc, ok := s.requestScope(w, params, "project")
+	if !ok || !required(w, params, "type") {
+		return
+	}
+
+	periodType, value := params.Get("type"), params.Get("value")
+	switch periodType {
+	case "PREVIOUS_VERSION":
+		if value != "" {
+			writeError(w, http.StatusBadRequest, "Unexpected value for type '%s'", periodType)
+			return
+		}
+	case "NUMBER_OF_DAYS":
+		if days, err := strconv.Atoi(value); err != nil || days < 1 || days > 90 {
+			writeError(w, http.StatusBadRequest, "Failed to parse number of days: %s", value)
+			return
+		}
+	case "REFERENCE_BRANCH":
+		if value == "" {
+			writeError(w, http.StatusBadRequest, "New code definition type '%s' requires a value", periodType)
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "Invalid type: %s", periodType)
+		return
+	}
+
+	s.newCodePeriods[sc] = &newCodePeriod{Type: periodType, Value: value}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// showNewCodePeriod returns the new code definition of the scope, or the one
+// it inherits. The global default is PREVIOUS_VERSION.
+func (s *Server) showNewCodePeriod(w http.ResponseWriter, params url.Values) {
+	sc, ok := s.requestScope(w, params, "project")
+	if !ok {
+		return
+	}
+
+	response := newCodePeriod{Type: "PREVIOUS_VERSION", Inherited: true}
+	for current, inherited := sc, false; ; inherited = true {
+		if period, ok := s.newCodePeriods[current]; ok {
+			response = newCodePeriod{Type: period.Type, Value: period.Value, Inherited: inherited}
+			break
+		}
+		if current, ok = current.parent(); !ok {
+			break
+		}
+	}
+	response.ProjectKey = sc.ProjectKey
+	response.BranchKey = sc.Branch
+	writeJSON(w, response)
+}
+
+func (s *Server) unsetNewCodePeriod(w http.ResponseWriter, params url.Values) {
+	sc, ok := s.requestScope(w, params, "project")
+	if !ok {
+		return
+	}
+
+	delete(s.newCodePeriods, sc)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) setSetting(w http.ResponseWriter, params url.Values) {
+	sc, ok := s.requestScope(w, params, "component")
+	if !ok || !required(w, params, "key", "value") {
+		return
+	}
+
+	if s.settings[sc] == nil {
+		s.settings[sc] = map[string]string{}
+	}
+	s.settings[sc][params.Get("key")] = params.Get("value")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// settingValues returns the values of the settings in keys, which may be
+// inherited. Settings without a value are left out.
+func (s *Server) settingValues(w http.ResponseWriter, params url.Values) {
+	sc, ok := s.requestScope(w, params, "component")
+	if !ok || !required(w, params, "keys") {
+		return
+	}
+
+	response := settingsResponse{Settings: []setting{}}
+	for _, key := range strings.Split(params.Get("keys"), ",") {
+		for current, inherited := sc, false; ; inherited = true {
+			if value, ok := s.settings[current][key]; ok {
+				response.Settings = append(response.Settings, setting{Key: key, Value: value, Inherited: inherited})
+				break
+			}
+			if current, ok = current.parent(); !ok {
+				break
+			}
+		}
+	}
+	writeJSON(w, response)
+}
+
+func (s *Server) resetSettings(w http.ResponseWriter, params url.Values) {
+	sc, ok := s.requestScope(w, params, "component")
+	if !ok || !required(w, params, "keys") {
+		return
+	}
+
+	for _, key := range strings.Split(params.Get("keys"), ",") {
+		delete(s.settings[sc], key)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
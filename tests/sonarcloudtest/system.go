@@ -0,0 +1,130 @@
+package sonarcloudtest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+func (s *Server) serverVersion(w http.ResponseWriter, params url.Values) {
+	// The only endpoint that answers with plain text
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, s.options.Version)
+}
+
+type globalNavigation struct {
+	Version  string            `json:"version"`
+	Edition  string            `json:"edition,omitempty"`
+	Settings map[string]string `json:"settings"`
+}
+
+func (s *Server) navigationGlobal(w http.ResponseWriter, params url.Values) {
+	navigation := globalNavigation{
+		Version:  s.options.Version,
+		Edition:  s.options.Edition,
+		Settings: map[string]string{},
+	}
+	if s.options.Platform == PlatformSonarCloud {
+		navigation.Settings["sonar.sonarcloud.enabled"] = "true"
+	}
+	writeJSON(w, navigation)
+}
+
+type plugin struct {
+	Key     string `json:"key"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type installedPlugins struct {
+	Plugins []*plugin `json:"plugins"`
+}
+
+type pendingPlugins struct {
+	Installing []*plugin `json:"installing"`
+	Updating   []*plugin `json:"updating"`
+	Removing   []*plugin `json:"removing"`
+}
+
+// installPlugin downloads the plugin like the real server, which only lists
+// it as installed after a restart. Until then it is pending installation.
+func (s *Server) installPlugin(w http.ResponseWriter, params url.Values) {
+	if s.options.Platform == PlatformSonarCloud || s.options.Edition != "community" {
+		writeError(w, http.StatusBadRequest, "This WS is unsupported in commercial edition. Please install plugin manually.")
+		return
+	}
+	if !required(w, params, "key") {
+		return
+	}
+
+	key := params.Get("key")
+	_, installed := s.installedPlugins[key]
+	_, installing := s.installingPlugins[key]
+	if installed || installing {
+		writeError(w, http.StatusBadRequest, "No plugin with key '%s' or plugin '%s' is already installed in latest version", key, key)
+		return
+	}
+
+	s.installingPlugins[key] = &plugin{Key: key, Name: key, Version: "1.0"}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) installedPluginList(w http.ResponseWriter, params url.Values) {
+	writeJSON(w, installedPlugins{Plugins: sortedPlugins(s.installedPlugins)})
+}
+
+func (s *Server) pendingPluginList(w http.ResponseWriter, params url.Values) {
+	writeJSON(w, pendingPlugins{
+		Installing: sortedPlugins(s.installingPlugins),
+		Updating:   []*plugin{},
+		Removing:   sortedPlugins(s.removingPlugins),
+	})
+}
+
+// uninstallPlugin marks the plugin for removal like the real server, it is
+// listed as installed until a restart. Plugins pending installation can not
+// be uninstalled.
+func (s *Server) uninstallPlugin(w http.ResponseWriter, params url.Values) {
+	if !required(w, params, "key") {
+		return
+	}
+
+	key := params.Get("key")
+	p, ok := s.installedPlugins[key]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Plugin [%s] is not installed", key)
+		return
+	}
+
+	s.removingPlugins[key] = p
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Restart applies the pending plugin installations and removals, like
+// restarting the real server does.
+func (s *Server) Restart() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, p := range s.installingPlugins {
+		s.installedPlugins[key] = p
+	}
+	for key := range s.removingPlugins {
+		delete(s.installedPlugins, key)
+	}
+	s.installingPlugins = map[string]*plugin{}
+	s.removingPlugins = map[string]*plugin{}
+}
+
+// sortedPlugins returns the plugins sorted by key.
+func sortedPlugins(plugins map[string]*plugin) []*plugin {
+	sorted := []*plugin{}
+	for _, p := range plugins {
+		sorted = append(sorted, p)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Key < sorted[j].Key
+	})
+	return sorted
+}
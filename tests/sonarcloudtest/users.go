@@ -0,0 +1,365 @@
+package sonarcloudtest
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type user struct {
+	Login    string `json:"login"`
+	Name     string `json:"name"`
+	Email    string `json:"email,omitempty"`
+	Active   bool   `json:"active"`
+	Local    bool   `json:"local"`
+	password string
+}
+
+type userResponse struct {
+	User *user `json:"user"`
+}
+
+type searchUsersResponse struct {
+	Paging paging  `json:"paging"`
+	Users  []*user `json:"users"`
+}
+
+type group struct {
+	ID           int    `json:"id"`
+	Organization string `json:"organization,omitempty"`
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	MembersCount int    `json:"membersCount"`
+	IsDefault    bool   `json:"default"`
+}
+
+type groupResponse struct {
+	Group *group `json:"group"`
+}
+
+type searchGroupsResponse struct {
+	Paging paging   `json:"paging"`
+	Groups []*group `json:"groups"`
+}
+
+type userToken struct {
+	Name           string `json:"name"`
+	CreatedAt      string `json:"createdAt"`
+	ExpirationDate string `json:"expirationDate,omitempty"`
+}
+
+type generateTokenResponse struct {
+	Login          string `json:"login"`
+	Name           string `json:"name"`
+	Token          string `json:"token"`
+	CreatedAt      string `json:"createdAt"`
+	ExpirationDate string `json:"expirationDate,omitempty"`
+}
+
+type searchTokensResponse struct {
+	Login  string       `json:"login"`
+	Tokens []*userToken `json:"userTokens"`
+}
+
+// timeFormat is the format of all timestamps returned by the API
+const timeFormat = "2006-01-02T15:04:05-0700"
+
+func (s *Server) createUser(w http.ResponseWriter, params url.Values) {
+	if !required(w, params, "login", "name") {
+		return
+	}
+
+	login := params.Get("login")
+	if u, ok := s.users[login]; ok && u.Active {
+		writeError(w, http.StatusBadRequest, "An active user with login '%s' already exists", login)
+		return
+	}
+
+	local := params.Get("local") != "false"
+	password := params.Get("password")
+	if local && password == "" {
+		writeError(w, http.StatusBadRequest, "Password is mandatory and must not be empty")
+		return
+	}
+	if !local && password != "" {
+		writeError(w, http.StatusBadRequest, "Password should only be set on local user")
+		return
+	}
+
+	u := &user{
+		Login:    login,
+		Name:     params.Get("name"),
+		Email:    params.Get("email"),
+		Active:   true,
+		Local:    local,
+		password: password,
+	}
+	s.users[login] = u
+	writeJSON(w, userResponse{User: u})
+}
+
+// activeUser returns the active user with the login parameter. A 404 response
+// is written when it does not exist.
+func (s *Server) activeUser(w http.ResponseWriter, params url.Values) (*user, bool) {
+	if !required(w, params, "login") {
+		return nil, false
+	}
+
+	u, ok := s.users[params.Get("login")]
+	if !ok || !u.Active {
+		writeError(w, http.StatusNotFound, "User '%s' doesn't exist", params.Get("login"))
+		return nil, false
+	}
+	return u, true
+}
+
+// searchUsers returns the active users whose login, name or email match q.
+func (s *Server) searchUsers(w http.ResponseWriter, params url.Values) {
+	q := params.Get("q")
+	results := []*user{}
+	for _, u := range s.users {
+		if !u.Active {
+			continue
+		}
+		if q != "" && !matches(u.Login, q) && !matches(u.Name, q) && !matches(u.Email, q) {
+			continue
+		}
+		results = append(results, u)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Login < results[j].Login })
+
+	start, end, paging, ok := page(w, params, len(results))
+	if !ok {
+		return
+	}
+	writeJSON(w, searchUsersResponse{Paging: paging, Users: results[start:end]})
+}
+
+func (s *Server) updateUser(w http.ResponseWriter, params url.Values) {
+	u, ok := s.activeUser(w, params)
+	if !ok {
+		return
+	}
+
+	if _, ok := params["email"]; ok {
+		u.Email = params.Get("email")
+	}
+	if _, ok := params["name"]; ok {
+		u.Name = params.Get("name")
+	}
+	writeJSON(w, userResponse{User: u})
+}
+
+func (s *Server) changePassword(w http.ResponseWriter, params url.Values) {
+	u, ok := s.activeUser(w, params)
+	if !ok || !required(w, params, "password") {
+		return
+	}
+	if !u.Local {
+		writeError(w, http.StatusBadRequest, "Password cannot be changed when external authentication is used")
+		return
+	}
+
+	u.password = params.Get("password")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deactivateUser deactivates the user and drops its tokens and permissions,
+// like the real API.
+func (s *Server) deactivateUser(w http.ResponseWriter, params url.Values) {
+	u, ok := s.activeUser(w, params)
+	if !ok {
+		return
+	}
+
+	u.Active = false
+	delete(s.tokens, u.Login)
+	for _, principals := range s.permissions {
+		delete(principals, principal{Login: u.Login})
+	}
+	writeJSON(w, userResponse{User: u})
+}
+
+func (s *Server) generateToken(w http.ResponseWriter, params url.Values) {
+	if !required(w, params, "name") {
+		return
+	}
+	u, ok := s.activeUser(w, params)
+	if !ok {
+		return
+	}
+
+	name := params.Get("name")
+	for _, token := range s.tokens[u.Login] {
+		if token.Name == name {
+			writeError(w, http.StatusBadRequest, "A user token for login '%s' and name '%s' already exists", u.Login, name)
+			return
+		}
+	}
+
+	token := &userToken{
+		Name:      name,
+		CreatedAt: time.Now().Format(timeFormat),
+	}
+	if expirationDate := params.Get("expirationDate"); expirationDate != "" {
+		date, err := time.Parse("2006-01-02", expirationDate)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "The date '%s' does not respect format 'yyyy-MM-dd'", expirationDate)
+			return
+		}
+		token.ExpirationDate = date.Format(timeFormat)
+	}
+	s.tokens[u.Login] = append(s.tokens[u.Login], token)
+
+	writeJSON(w, generateTokenResponse{
+		Login:          u.Login,
+		Name:           token.Name,
+		Token:          fmt.Sprintf("squ_%036d", s.newID()),
+		CreatedAt:      token.CreatedAt,
+		ExpirationDate: token.ExpirationDate,
+	})
+}
+
+func (s *Server) searchTokens(w http.ResponseWriter, params url.Values) {
+	u, ok := s.activeUser(w, params)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, searchTokensResponse{
+		Login:  u.Login,
+		Tokens: append([]*userToken{}, s.tokens[u.Login]...),
+	})
+}
+
+// revokeToken revokes the token. Like the real API it succeeds when the token
+// does not exist.
+func (s *Server) revokeToken(w http.ResponseWriter, params url.Values) {
+	if !required(w, params, "name") {
+		return
+	}
+	u, ok := s.activeUser(w, params)
+	if !ok {
+		return
+	}
+
+	tokens := s.tokens[u.Login][:0]
+	for _, token := range s.tokens[u.Login] {
+		if token.Name != params.Get("name") {
+			tokens = append(tokens, token)
+		}
+	}
+	s.tokens[u.Login] = tokens
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) createGroup(w http.ResponseWriter, params url.Values) {
+	organization, ok := s.organization(w, params)
+	if !ok || !required(w, params, "name") {
+		return
+	}
+
+	name := params.Get("name")
+	if s.findGroup(name, organization) != nil {
+		writeError(w, http.StatusBadRequest, "Group '%s' already exists", name)
+		return
+	}
+
+	g := &group{
+		ID:           s.newID(),
+		Organization: organization,
+		Name:         name,
+		Description:  params.Get("description"),
+	}
+	s.groups[g.ID] = g
+	writeJSON(w, groupResponse{Group: g})
+}
+
+func (s *Server) findGroup(name string, organization string) *group {
+	for _, g := range s.groups {
+		if g.Name == name && g.Organization == organization {
+			return g
+		}
+	}
+	return nil
+}
+
+// groupByID returns the group with the id parameter. A 404 response is written
+// when it does not exist.
+func (s *Server) groupByID(w http.ResponseWriter, params url.Values) (*group, bool) {
+	if !required(w, params, "id") {
+		return nil, false
+	}
+
+	id, err := strconv.Atoi(params.Get("id"))
+	g, ok := s.groups[id]
+	if err != nil || !ok {
+		writeError(w, http.StatusNotFound, "Could not find a user group with id '%s'.", params.Get("id"))
+		return nil, false
+	}
+	return g, true
+}
+
+func (s *Server) searchGroups(w http.ResponseWriter, params url.Values) {
+	organization, ok := s.organization(w, params)
+	if !ok {
+		return
+	}
+
+	q := params.Get("q")
+	results := []*group{}
+	for _, g := range s.groups {
+		if g.Organization != organization || q != "" && !matches(g.Name, q) {
+			continue
+		}
+		results = append(results, g)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	start, end, paging, ok := page(w, params, len(results))
+	if !ok {
+		return
+	}
+	writeJSON(w, searchGroupsResponse{Paging: paging, Groups: results[start:end]})
+}
+
+func (s *Server) updateGroup(w http.ResponseWriter, params url.Values) {
+	g, ok := s.groupByID(w, params)
+	if !ok {
+		return
+	}
+
+	if name := params.Get("name"); name != "" && name != g.Name {
+		if s.findGroup(name, g.Organization) != nil {
+			writeError(w, http.StatusBadRequest, "Group '%s' already exists", name)
+			return
+		}
+		g.Name = name
+	}
+	if _, ok := params["description"]; ok {
+		g.Description = params.Get("description")
+	}
+	writeJSON(w, groupResponse{Group: g})
+}
+
+func (s *Server) deleteGroup(w http.ResponseWriter, params url.Values) {
+	g, ok := s.groupByID(w, params)
+	if !ok {
+		return
+	}
+	if g.IsDefault {
+		writeError(w, http.StatusBadRequest, "Default group '%s' cannot be used to perform this action", g.Name)
+		return
+	}
+
+	delete(s.groups, g.ID)
+	for scope, principals := range s.permissions {
+		if scope.Organization == g.Organization {
+			delete(principals, principal{GroupName: g.Name})
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}